@@ -0,0 +1,341 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filterExpr is a parsed [?(...)] filter: a disjunction of conjunctions of
+// conditions, so "a && b || c" is stored as [[a, b], [c]].
+type filterExpr struct {
+	source string // original "?(...)" text, for error messages
+	anyOf  [][]filterCondition
+}
+
+// filterCondition compares a value relative to the current element ("@")
+// against a literal. An empty op tests that the relative path exists.
+type filterCondition struct {
+	path    []pathSegment // nil selects the element itself
+	op      string
+	literal interface{} // string, float64, bool or nil
+}
+
+// filterOperators are the comparison operators accepted in filters, longest first
+// so that "<=" is matched before "<".
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseFilter parses the contents of a filter selector, without the brackets.
+//
+// Syntax:
+//   - ?(@.path op literal) compares a value relative to the element
+//   - ?(@.path) keeps elements where the relative path exists
+//   - ?(@ op literal) compares the element itself
+//   - Operators: ==, !=, <, <=, >, >=
+//   - Literals: 'string', "string", numbers, true, false, null
+//   - Conditions combine with && and || (&& binds tighter); no parentheses
+//
+// Example:
+//   filter, _ := parseFilter("?(@.resource=='billing' && @.level >= 2)")
+//
+// Returns an error if the expression is malformed.
+func parseFilter(s string) (*filterExpr, error) {
+	if !strings.HasPrefix(s, "?(") || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid filter '[%s]': must have the form [?(...)]", s)
+	}
+
+	p := &filterParser{src: s[2 : len(s)-1]}
+	expr := &filterExpr{source: s}
+	conjunction := []filterCondition{}
+	for {
+		cond, err := p.condition()
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter '[%s]': %w", s, err)
+		}
+		conjunction = append(conjunction, cond)
+
+		p.skipSpace()
+		switch {
+		case p.done():
+			expr.anyOf = append(expr.anyOf, conjunction)
+			return expr, nil
+		case p.consume("&&"):
+		case p.consume("||"):
+			expr.anyOf = append(expr.anyOf, conjunction)
+			conjunction = []filterCondition{}
+		default:
+			return nil, fmt.Errorf("invalid filter '[%s]': unexpected '%s'", s, p.rest())
+		}
+	}
+}
+
+// depth returns the combined depth of the relative paths in the filter.
+func (f *filterExpr) depth() int {
+	depth := 0
+	for _, conjunction := range f.anyOf {
+		for _, cond := range conjunction {
+			depth += segmentsDepth(cond.path)
+		}
+	}
+	return depth
+}
+
+// matches reports whether elem satisfies the filter. Relative paths are
+// evaluated with w so they draw from the same selection budget.
+func (f *filterExpr) matches(w *pathWalker, elem interface{}) (bool, error) {
+	for _, conjunction := range f.anyOf {
+		all := true
+		for _, cond := range conjunction {
+			ok, err := cond.matches(w, elem)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matches evaluates one condition. A relative path that does not resolve
+// fails every comparison, including !=.
+func (c filterCondition) matches(w *pathWalker, elem interface{}) (bool, error) {
+	value, err := w.walk(elem, c.path, "@")
+	if err == errSelectionLimit {
+		return false, err
+	}
+	if err != nil {
+		return false, nil
+	}
+	if c.op == "" {
+		return true, nil
+	}
+	return compareFilterValues(value, c.op, c.literal), nil
+}
+
+// compareFilterValues applies op to a claim value and a literal. Equality
+// requires matching types; ordering applies to two numbers or two strings.
+// Arrays and objects never compare.
+func compareFilterValues(value interface{}, op string, literal interface{}) bool {
+	switch op {
+	case "==":
+		return filterValuesEqual(value, literal)
+	case "!=":
+		if !isFilterScalar(value) {
+			return false
+		}
+		return !filterValuesEqual(value, literal)
+	}
+
+	var cmp int
+	switch v := filterNumber(value).(type) {
+	case float64:
+		l, ok := literal.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case v < l:
+			cmp = -1
+		case v > l:
+			cmp = 1
+		}
+	case string:
+		l, ok := literal.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(v, l)
+	default:
+		return false
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// filterValuesEqual compares two scalars of the same JSON type.
+func filterValuesEqual(value, literal interface{}) bool {
+	if !isFilterScalar(value) {
+		return false
+	}
+	return filterNumber(value) == literal
+}
+
+// filterNumber converts a json.Number claim to float64 so it compares with
+// numeric literals. Other values are returned unchanged.
+func filterNumber(value interface{}) interface{} {
+	if n, ok := value.(json.Number); ok {
+		if f, ok := claimNumber(n); ok {
+			return f
+		}
+	}
+	return value
+}
+
+// isFilterScalar reports whether v is a JSON string, number, boolean or null.
+func isFilterScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, float64, json.Number, bool, nil:
+		return true
+	}
+	return false
+}
+
+// filterParser is a small cursor over a filter expression.
+type filterParser struct {
+	src string
+	pos int
+}
+
+func (p *filterParser) done() bool { return p.pos >= len(p.src) }
+
+func (p *filterParser) rest() string { return p.src[p.pos:] }
+
+func (p *filterParser) skipSpace() {
+	for !p.done() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// consume advances past tok if the input continues with it.
+func (p *filterParser) consume(tok string) bool {
+	if strings.HasPrefix(p.rest(), tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+// condition parses "@path [op literal]".
+func (p *filterParser) condition() (filterCondition, error) {
+	p.skipSpace()
+	if !p.consume("@") {
+		return filterCondition{}, fmt.Errorf("expected '@' at '%s'", p.rest())
+	}
+
+	var cond filterCondition
+	pathText := p.relativePath()
+	if pathText != "" {
+		path := strings.TrimPrefix(pathText, ".")
+		if path == "" || strings.HasPrefix(pathText, "..") {
+			return filterCondition{}, fmt.Errorf("invalid relative path '@%s'", pathText)
+		}
+		segments, err := parseSegments(path, !strings.HasPrefix(pathText, "."))
+		if err != nil {
+			return filterCondition{}, err
+		}
+		cond.path = segments
+	}
+
+	p.skipSpace()
+	for _, op := range filterOperators {
+		if p.consume(op) {
+			cond.op = op
+			break
+		}
+	}
+	if cond.op == "" {
+		return cond, nil
+	}
+
+	p.skipSpace()
+	literal, err := p.literal()
+	if err != nil {
+		return filterCondition{}, err
+	}
+	cond.literal = literal
+	return cond, nil
+}
+
+// relativePath reads the path following '@' up to whitespace, an operator or
+// the end of the expression, skipping over brackets and quoted keys.
+func (p *filterParser) relativePath() string {
+	start := p.pos
+	depth := 0
+	var quote byte
+	for ; !p.done(); p.pos++ {
+		c := p.src[p.pos]
+		if quote != 0 {
+			if c == '\\' {
+				p.pos++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ' ', '\t', '=', '!', '<', '>', '&', '|':
+			if depth == 0 {
+				return p.src[start:p.pos]
+			}
+		}
+	}
+	return p.src[start:]
+}
+
+// literal parses a quoted string, number, true, false or null.
+func (p *filterParser) literal() (interface{}, error) {
+	if p.done() {
+		return nil, fmt.Errorf("expected literal")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '\'' || c == '"':
+		var b strings.Builder
+		for i := p.pos + 1; i < len(p.src); i++ {
+			switch p.src[i] {
+			case '\\':
+				if i+1 < len(p.src) {
+					i++
+					b.WriteByte(p.src[i])
+				}
+			case c:
+				p.pos = i + 1
+				return b.String(), nil
+			default:
+				b.WriteByte(p.src[i])
+			}
+		}
+		return nil, fmt.Errorf("unterminated string literal")
+
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for !p.done() && strings.IndexByte("+-.0123456789eE", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", p.src[start:p.pos])
+		}
+		return n, nil
+	}
+
+	for word, value := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+		if p.consume(word) {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("expected literal at '%s'", p.rest())
+}
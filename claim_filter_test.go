@@ -0,0 +1,166 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseFilter verifies filter expression syntax
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		wantErr bool
+	}{
+		{"string equality", "?(@.resource=='billing')", false},
+		{"double-quoted string", `?(@.resource == "billing")`, false},
+		{"number comparison", "?(@.level >= 2)", false},
+		{"negative number", "?(@.offset > -1.5)", false},
+		{"boolean", "?(@.active == true)", false},
+		{"null", "?(@.deleted == null)", false},
+		{"existence", "?(@.actions)", false},
+		{"element itself", "?(@ == 'admin')", false},
+		{"relative index", "?(@[0] == 'x')", false},
+		{"nested relative path", "?(@.owner.id == 'u1')", false},
+		{"quoted relative key", `?(@["https://example.com/tier"] == 'gold')`, false},
+		{"and", "?(@.resource=='billing' && @.level > 1)", false},
+		{"or", "?(@.resource=='billing' || @.resource=='reports')", false},
+		{"string with bracket", "?(@.name == 'a]b')", false},
+		{"missing parens", "?@.a=='b'", true},
+		{"missing at", "?(resource=='billing')", true},
+		{"missing literal", "?(@.resource==)", true},
+		{"unterminated string", "?(@.resource=='billing)", true},
+		{"invalid number", "?(@.level > 1-2)", true},
+		{"unknown literal", "?(@.resource == billing)", true},
+		{"trailing garbage", "?(@.a == 1 2)", true},
+		{"empty relative key", "?(@. == 1)", true},
+		{"dangling and", "?(@.a == 1 &&)", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseFilter(%q) error = %v, wantErr %v", tt.filter, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestExtractClaim_Filters verifies filter selection against arrays of objects and scalars
+func TestExtractClaim_Filters(t *testing.T) {
+	data := map[string]interface{}{
+		"permissions": []interface{}{
+			map[string]interface{}{"resource": "billing", "actions": []interface{}{"read"}, "level": float64(1)},
+			map[string]interface{}{"resource": "reports", "actions": []interface{}{"read", "export"}, "level": float64(3)},
+			map[string]interface{}{"resource": "billing", "actions": []interface{}{"write"}, "level": float64(2), "active": false},
+			map[string]interface{}{"resource": "admin"},
+		},
+		"keys":  []interface{}{map[string]interface{}{"id": json.Number("9007199254740993"), "weight": json.Number("2.5")}},
+		"roles": []interface{}{"admin", "user", "auditor"},
+		"name":  "not an array",
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"issue example", "permissions[?(@.resource=='billing')].actions",
+			[]interface{}{[]interface{}{"read"}, []interface{}{"write"}}, false},
+		{"filter then index", "permissions[?(@.resource=='reports')].actions[0]", []interface{}{"read"}, false},
+		{"numeric comparison", "permissions[?(@.level >= 2)].resource", []interface{}{"reports", "billing"}, false},
+		{"and", "permissions[?(@.resource=='billing' && @.level > 1)].level", []interface{}{float64(2)}, false},
+		{"or", "permissions[?(@.level == 1 || @.level == 3)].resource", []interface{}{"billing", "reports"}, false},
+		{"existence", "permissions[?(@.active)].resource", []interface{}{"billing"}, false},
+		{"not equals skips missing", "permissions[?(@.level != 1)].resource", []interface{}{"reports", "billing"}, false},
+		{"boolean literal", "permissions[?(@.active == false)].level", []interface{}{float64(2)}, false},
+		{"scalar elements", "roles[?(@ != 'user')]", []interface{}{"admin", "auditor"}, false},
+		{"string ordering", "roles[?(@ < 'b')]", []interface{}{"admin", "auditor"}, false},
+		{"json.Number equality", "keys[?(@.weight == 2.5)].id", []interface{}{json.Number("9007199254740993")}, false},
+		{"json.Number ordering", "keys[?(@.weight > 2)].weight", []interface{}{json.Number("2.5")}, false},
+		{"no matches", "permissions[?(@.resource=='missing')]", nil, true},
+		{"type mismatch never equal", "permissions[?(@.level == '1')]", nil, true},
+		{"filter on non-array", "name[?(@ == 'x')]", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ExtractClaim(data, tt.path, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractClaim(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("ExtractClaim(%q) = %#v, want %#v", tt.path, value, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractClaim_FilterDepth verifies paths inside filters count toward maxDepth
+func TestExtractClaim_FilterDepth(t *testing.T) {
+	data := map[string]interface{}{
+		"orgs": []interface{}{
+			map[string]interface{}{"owner": map[string]interface{}{"id": "u1"}, "name": "Acme"},
+		},
+	}
+
+	// orgs, [?(...)], name = 3 segments, plus owner.id = 2 inside the filter
+	path := "orgs[?(@.owner.id == 'u1')].name"
+	if _, err := ExtractClaim(data, path, 5); err != nil {
+		t.Errorf("ExtractClaim() depth 5 with max 5 unexpected error: %v", err)
+	}
+	if _, err := ExtractClaim(data, path, 4); err == nil {
+		t.Error("ExtractClaim() depth 5 with max 4 expected error, got nil")
+	}
+}
+
+// TestExtractClaim_SelectionLimit verifies projections over hostile arrays are bounded
+func TestExtractClaim_SelectionLimit(t *testing.T) {
+	small := make([]interface{}, 100)
+	for i := range small {
+		small[i] = map[string]interface{}{"id": float64(i)}
+	}
+	large := make([]interface{}, maxPathSelections+1)
+	for i := range large {
+		large[i] = "x"
+	}
+	// 40 x 40 nested arrays: each level is small but the product exceeds the budget
+	grid := make([]interface{}, 40)
+	for i := range grid {
+		row := make([]interface{}, 40)
+		for j := range row {
+			row[j] = "x"
+		}
+		grid[i] = row
+	}
+
+	data := map[string]interface{}{"small": small, "large": large, "grid": grid}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"within budget", "small[?(@.id < 10)].id", false},
+		{"wildcard over large array", "large[*]", true},
+		{"filter over large array", "large[?(@ == 'y')]", true},
+		{"nested projections", "grid[*][*]", true},
+		{"index avoids projection", "large[0]", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ExtractClaim(data, tt.path, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractClaim(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(err.Error(), "array elements") {
+				t.Errorf("ExtractClaim(%q) error = %v, want selection limit error", tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// segmentKind identifies how a claim path segment selects values.
+type segmentKind int
+
+const (
+	segmentKey      segmentKind = iota // object member: "user"
+	segmentIndex                       // single array element: [0], [-1]
+	segmentWildcard                    // every array element: [*]
+	segmentSlice                       // array range: [0:2], [1:], [:-1]
+	segmentToken                       // JSON Pointer token: object member or array index
+	segmentFilter                      // array elements matching a filter: [?(@.a=='b')]
+)
+
+// Claim path syntaxes selectable per claim mapping.
+const (
+	pathSyntaxDot     = "dot"
+	pathSyntaxPointer = "pointer"
+)
+
+// pathSegment is one navigation step of a parsed claim path.
+type pathSegment struct {
+	kind     segmentKind
+	key      string // segmentKey
+	index    int    // segmentIndex, negative counts from the end
+	start    int    // segmentSlice, valid when hasStart
+	end      int    // segmentSlice, valid when hasEnd
+	hasStart bool
+	hasEnd   bool
+	filter   *filterExpr // segmentFilter
+}
+
+// String returns the segment as written in a claim path.
+func (s pathSegment) String() string {
+	switch s.kind {
+	case segmentIndex:
+		return "[" + strconv.Itoa(s.index) + "]"
+	case segmentWildcard:
+		return "[*]"
+	case segmentFilter:
+		return "[" + s.filter.source + "]"
+	case segmentSlice:
+		var b strings.Builder
+		b.WriteString("[")
+		if s.hasStart {
+			b.WriteString(strconv.Itoa(s.start))
+		}
+		b.WriteString(":")
+		if s.hasEnd {
+			b.WriteString(strconv.Itoa(s.end))
+		}
+		b.WriteString("]")
+		return b.String()
+	default:
+		return s.key
+	}
+}
+
+// parseClaimPath splits a claim path into navigation segments.
+//
+// Syntax:
+//   - Dots separate object keys: "user.profile.email"
+//   - A backslash escapes the next character in a key: `https://example\.com/roles`
+//   - ["key"] or ['key'] quotes a key containing dots or brackets: `["https://example.com/roles"]`
+//   - [n] selects an array element; negative n counts from the end: "roles[-1]"
+//   - [*] selects every element: "orgs[*].id"
+//   - [start:end] selects a half-open range, either bound optional: "addresses[0:2].city"
+//   - [?(...)] keeps elements matching a filter: "permissions[?(@.resource=='billing')].actions"
+//   - Selectors can be chained: "matrix[0][1]"
+//
+// Every dot must be followed by a key or a quoted key. See parseFilter for
+// the filter expression syntax.
+//
+// Example:
+//   segments, _ := parseClaimPath("orgs[*].id")
+//   // Returns: [orgs, [*], id]
+//
+//   segments, _ := parseClaimPath(`["https://example.com/claims"].tenant`)
+//   // Returns: [https://example.com/claims, tenant]
+//
+// Returns an error if the path is empty or malformed.
+func parseClaimPath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("claim path cannot be empty")
+	}
+	return parseSegments(path, false)
+}
+
+// parseSegments implements parseClaimPath. A relative path (the part after
+// '@' in a filter) may also start with an array selector, as in "@[0]".
+func parseSegments(path string, relative bool) ([]pathSegment, error) {
+	var segments []pathSegment
+	i := 0
+	for {
+		// Object key, either quoted or up to the next unescaped separator
+		var key string
+		var err error
+		switch {
+		case relative && i == 0 && path[0] == '[' && !isQuotedSelector(path, 0):
+			// leading selector, handled below
+		case isQuotedSelector(path, i):
+			key, i, err = parseQuotedKey(path, i)
+		default:
+			key, i, err = parsePlainKey(path, i)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid claim path '%s': %w", path, err)
+		}
+		if key != "" {
+			segments = append(segments, pathSegment{kind: segmentKey, key: key})
+		}
+
+		// Any number of array selectors or quoted keys
+		for i < len(path) && path[i] == '[' {
+			if isQuotedSelector(path, i) {
+				key, i, err = parseQuotedKey(path, i)
+				if err != nil {
+					return nil, fmt.Errorf("invalid claim path '%s': %w", path, err)
+				}
+				segments = append(segments, pathSegment{kind: segmentKey, key: key})
+				continue
+			}
+
+			end, err := selectorEnd(path, i)
+			if err != nil {
+				return nil, fmt.Errorf("invalid claim path '%s': %w", path, err)
+			}
+			seg, err := parseArraySelector(path[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid claim path '%s': %w", path, err)
+			}
+			segments = append(segments, seg)
+			i += end + 1
+		}
+
+		if i == len(path) {
+			return segments, nil
+		}
+		if path[i] != '.' {
+			return nil, fmt.Errorf("invalid claim path '%s': unexpected '%c' at position %d", path, path[i], i)
+		}
+		i++
+	}
+}
+
+// isQuotedSelector reports whether a quoted key (["..."] or ['...']) starts at i.
+func isQuotedSelector(path string, i int) bool {
+	return i+1 < len(path) && path[i] == '[' && (path[i+1] == '"' || path[i+1] == '\'')
+}
+
+// parsePlainKey reads an unquoted key starting at i, resolving backslash
+// escapes. Returns the key and the index of the first unconsumed byte.
+func parsePlainKey(path string, i int) (string, int, error) {
+	var b strings.Builder
+	start := i
+	for i < len(path) {
+		c := path[i]
+		if c == '.' || c == '[' || c == ']' {
+			break
+		}
+		if c == '\\' {
+			if i+1 == len(path) {
+				return "", i, fmt.Errorf("trailing escape character")
+			}
+			i++
+			c = path[i]
+		}
+		b.WriteByte(c)
+		i++
+	}
+	if i == start {
+		return "", i, fmt.Errorf("expected key at position %d", i)
+	}
+	return b.String(), i, nil
+}
+
+// parseQuotedKey reads a ["..."] or ['...'] key starting at the '[' at i.
+// Inside the quotes a backslash escapes the next character. Returns the key
+// and the index just past the closing ']'.
+func parseQuotedKey(path string, i int) (string, int, error) {
+	quote := path[i+1]
+	var b strings.Builder
+	for j := i + 2; j < len(path); j++ {
+		c := path[j]
+		switch {
+		case c == '\\':
+			if j+1 == len(path) {
+				return "", j, fmt.Errorf("trailing escape character")
+			}
+			j++
+			b.WriteByte(path[j])
+		case c == quote:
+			if j+1 == len(path) || path[j+1] != ']' {
+				return "", j, fmt.Errorf("expected ']' after quoted key at position %d", j+1)
+			}
+			if b.Len() == 0 {
+				return "", j, fmt.Errorf("quoted key cannot be empty")
+			}
+			return b.String(), j + 2, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", len(path), fmt.Errorf("unterminated quoted key")
+}
+
+// parseJSONPointer splits an RFC 6901 JSON Pointer into navigation segments.
+// Each reference token selects an object member or, on arrays, an index
+// ("0", "1", ...). "~1" decodes to "/" and "~0" to "~", so the pointer
+// "/https:~1~1example.com~1roles" names the key "https://example.com/roles".
+//
+// The empty pointer (the whole document) is not a claim and is rejected.
+//
+// Returns an error if the pointer does not start with '/' or contains an
+// invalid '~' escape.
+func parseJSONPointer(pointer string) ([]pathSegment, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer '%s': must start with '/'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	segments := make([]pathSegment, 0, len(tokens))
+	for _, token := range tokens {
+		for i := 0; i < len(token); i++ {
+			if token[i] == '~' && (i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+				return nil, fmt.Errorf("invalid JSON pointer '%s': '~' must be followed by '0' or '1'", pointer)
+			}
+		}
+		token = strings.ReplaceAll(token, "~1", "/")
+		token = strings.ReplaceAll(token, "~0", "~")
+		segments = append(segments, pathSegment{kind: segmentToken, key: token})
+	}
+	return segments, nil
+}
+
+// parsePathSyntax parses path using the named syntax: "dot" (the default when
+// empty) or "pointer" for RFC 6901 JSON Pointers.
+func parsePathSyntax(path, syntax string) ([]pathSegment, error) {
+	switch syntax {
+	case "", pathSyntaxDot:
+		return parseClaimPath(path)
+	case pathSyntaxPointer:
+		return parseJSONPointer(path)
+	default:
+		return nil, fmt.Errorf("invalid pathSyntax '%s', must be 'dot' or 'pointer'", syntax)
+	}
+}
+
+// selectorEnd returns the offset from i of the ']' closing the selector that
+// opens at i. Brackets nested inside the selector and quoted strings (as used
+// by filters) are skipped.
+func selectorEnd(path string, i int) (int, error) {
+	depth := 0
+	var quote byte
+	for j := i; j < len(path); j++ {
+		c := path[j]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				j++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return j - i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unterminated '['")
+}
+
+// parseArraySelector parses the contents of a [...] selector.
+func parseArraySelector(s string) (pathSegment, error) {
+	if s == "*" {
+		return pathSegment{kind: segmentWildcard}, nil
+	}
+
+	if strings.HasPrefix(s, "?") {
+		filter, err := parseFilter(s)
+		if err != nil {
+			return pathSegment{}, err
+		}
+		return pathSegment{kind: segmentFilter, filter: filter}, nil
+	}
+
+	if colon := strings.IndexByte(s, ':'); colon >= 0 {
+		seg := pathSegment{kind: segmentSlice}
+		if lo := s[:colon]; lo != "" {
+			n, err := strconv.Atoi(lo)
+			if err != nil {
+				return pathSegment{}, fmt.Errorf("invalid slice start '%s'", lo)
+			}
+			seg.start, seg.hasStart = n, true
+		}
+		if hi := s[colon+1:]; hi != "" {
+			n, err := strconv.Atoi(hi)
+			if err != nil {
+				return pathSegment{}, fmt.Errorf("invalid slice end '%s'", hi)
+			}
+			seg.end, seg.hasEnd = n, true
+		}
+		return seg, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return pathSegment{}, fmt.Errorf("invalid array selector '[%s]'", s)
+	}
+	return pathSegment{kind: segmentIndex, index: n}, nil
+}
+
+// sliceBounds resolves a slice segment against an array length, counting
+// negative bounds from the end and clamping to the array.
+func (s pathSegment) sliceBounds(length int) (int, int) {
+	lo, hi := 0, length
+	if s.hasStart {
+		lo = s.start
+	}
+	if s.hasEnd {
+		hi = s.end
+	}
+	if lo < 0 {
+		lo += length
+	}
+	if hi < 0 {
+		hi += length
+	}
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > length {
+		hi = length
+	}
+	if lo > hi {
+		lo = hi
+	}
+	return lo, hi
+}
+
+// maxPathSelections bounds the number of array elements that wildcards,
+// slices and filters may examine while evaluating one claim path, so a token
+// with large or deeply nested arrays cannot make evaluation expensive.
+const maxPathSelections = 1000
+
+// errSelectionLimit aborts a walk that exceeds maxPathSelections.
+var errSelectionLimit = fmt.Errorf("claim path selects more than %d array elements", maxPathSelections)
+
+// pathWalker evaluates parsed segments against claim data, tracking the
+// remaining selection budget shared by every projection and filter.
+type pathWalker struct {
+	budget int
+}
+
+// newPathWalker returns a walker with the full selection budget.
+func newPathWalker() *pathWalker {
+	return &pathWalker{budget: maxPathSelections}
+}
+
+// spend consumes n selections from the budget, failing once it is exhausted.
+func (w *pathWalker) spend(n int) error {
+	w.budget -= n
+	if w.budget < 0 {
+		return errSelectionLimit
+	}
+	return nil
+}
+
+// walk applies segments to value. parent names the value for error
+// messages. Projections ([*], slices and filters) collect the results of the
+// remaining segments into an array, skipping elements the remaining path does
+// not match. Exceeding the selection budget aborts the whole walk.
+func (w *pathWalker) walk(value interface{}, segments []pathSegment, parent string) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	seg, rest := segments[0], segments[1:]
+
+	if seg.kind == segmentKey {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid claim path: '%s' is not an object", parent)
+		}
+		nested, exists := obj[seg.key]
+		if !exists {
+			return nil, errClaimNotFound
+		}
+		return w.walk(nested, rest, seg.key)
+	}
+
+	if seg.kind == segmentToken {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			nested, exists := v[seg.key]
+			if !exists {
+				return nil, errClaimNotFound
+			}
+			return w.walk(nested, rest, seg.key)
+		case []interface{}:
+			idx, ok := pointerIndex(seg.key)
+			if !ok || idx >= len(v) {
+				return nil, errClaimNotFound
+			}
+			return w.walk(v[idx], rest, parent+"/"+seg.key)
+		default:
+			return nil, fmt.Errorf("invalid claim path: '%s' is not an object or array", parent)
+		}
+	}
+
+	arr, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid claim path: '%s' is not an array", parent)
+	}
+	label := parent + seg.String()
+
+	if seg.kind == segmentIndex {
+		idx := seg.index
+		if idx < 0 {
+			idx += len(arr)
+		}
+		if idx < 0 || idx >= len(arr) {
+			return nil, errClaimNotFound
+		}
+		return w.walk(arr[idx], rest, label)
+	}
+
+	elems := arr
+	if seg.kind == segmentSlice {
+		lo, hi := seg.sliceBounds(len(arr))
+		elems = arr[lo:hi]
+	}
+	if err := w.spend(len(elems)); err != nil {
+		return nil, err
+	}
+
+	results := make([]interface{}, 0, len(elems))
+	for _, elem := range elems {
+		if seg.kind == segmentFilter {
+			matched, err := seg.filter.matches(w, elem)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				continue
+			}
+		}
+		result, err := w.walk(elem, rest, label)
+		if err == errSelectionLimit {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, errClaimNotFound
+	}
+	return results, nil
+}
+
+// pointerIndex parses an RFC 6901 array index: "0" or a decimal number
+// without leading zeros. "-" (past the end) and anything else never match.
+func pointerIndex(token string) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+	for i := 0; i < len(token); i++ {
+		if token[i] < '0' || token[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// segmentsDepth returns the nesting depth of segments for MaxClaimDepth
+// checks. Each segment counts as one level, plus the relative paths used
+// inside filter expressions.
+func segmentsDepth(segments []pathSegment) int {
+	depth := len(segments)
+	for _, seg := range segments {
+		if seg.kind == segmentFilter {
+			depth += seg.filter.depth()
+		}
+	}
+	return depth
+}
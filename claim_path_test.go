@@ -0,0 +1,145 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"testing"
+)
+
+// TestParseClaimPath verifies claim path syntax and segment counts
+func TestParseClaimPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    string // segments joined with "|"
+		wantErr bool
+	}{
+		{"single key", "sub", "sub", false},
+		{"nested keys", "user.profile.email", "user|profile|email", false},
+		{"index", "groups[0]", "groups|[0]", false},
+		{"negative index", "roles[-1]", "roles|[-1]", false},
+		{"wildcard", "orgs[*].id", "orgs|[*]|id", false},
+		{"slice", "addresses[0:2].city", "addresses|[0:2]|city", false},
+		{"open slice", "items[1:]", "items|[1:]", false},
+		{"chained selectors", "matrix[0][-1]", "matrix|[0]|[-1]", false},
+		{"empty", "", "", true},
+		{"leading dot", ".sub", "", true},
+		{"trailing dot", "sub.", "", true},
+		{"double dot", "a..b", "", true},
+		{"leading selector", "[0]", "", true},
+		{"unterminated selector", "groups[0", "", true},
+		{"stray bracket", "groups]", "", true},
+		{"non-numeric index", "groups[first]", "", true},
+		{"invalid slice bound", "groups[a:2]", "", true},
+		{"key after selector without dot", "groups[0]name", "", true},
+		{"escaped dot", `https://example\.com/roles`, "https://example.com/roles", false},
+		{"escaped backslash", `a\\b.c`, `a\b|c`, false},
+		{"escaped bracket", `weird\[0\]`, "weird[0]", false},
+		{"colon key", "cognito:groups[0]", "cognito:groups|[0]", false},
+		{"double-quoted key", `["https://example.com/roles"]`, "https://example.com/roles", false},
+		{"single-quoted key", `['https://example.com/roles'][0]`, "https://example.com/roles|[0]", false},
+		{"quoted key after key", `custom["a.b"].c`, "custom|a.b|c", false},
+		{"quoted key after dot", `custom.["a.b"]`, "custom|a.b", false},
+		{"quoted key with bracket and quote", `["a]\"b"]`, `a]"b`, false},
+		{"trailing escape", `sub\`, "", true},
+		{"unterminated quoted key", `["https://example.com`, "", true},
+		{"quoted key without bracket", `["a"b`, "", true},
+		{"empty quoted key", `[""]`, "", true},
+		{"filter", "permissions[?(@.resource=='billing')].actions", "permissions|[?(@.resource=='billing')]|actions", false},
+		{"filter with bracket in string", "items[?(@.name == 'a]b')]", "items|[?(@.name == 'a]b')]", false},
+		{"malformed filter", "items[?(@.name ==)]", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := parseClaimPath(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClaimPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got string
+			for i, seg := range segments {
+				if i > 0 {
+					got += "|"
+				}
+				got += seg.String()
+			}
+			if got != tt.want {
+				t.Errorf("parseClaimPath(%q) = %s, want %s", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPathSegment_SliceBounds verifies negative and out-of-range slice bounds are clamped
+func TestPathSegment_SliceBounds(t *testing.T) {
+	tests := []struct {
+		selector string
+		length   int
+		wantLo   int
+		wantHi   int
+	}{
+		{"0:2", 5, 0, 2},
+		{"1:", 5, 1, 5},
+		{":-1", 5, 0, 4},
+		{"-2:", 5, 3, 5},
+		{"0:10", 3, 0, 3},
+		{"-10:2", 3, 0, 2},
+		{"4:2", 5, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			seg, err := parseArraySelector(tt.selector)
+			if err != nil {
+				t.Fatalf("parseArraySelector() error: %v", err)
+			}
+			lo, hi := seg.sliceBounds(tt.length)
+			if lo != tt.wantLo || hi != tt.wantHi {
+				t.Errorf("sliceBounds(%d) = [%d:%d], want [%d:%d]", tt.length, lo, hi, tt.wantLo, tt.wantHi)
+			}
+		})
+	}
+}
+
+// TestParseJSONPointer verifies RFC 6901 pointer parsing and escapes
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		name    string
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{"single token", "/sub", []string{"sub"}, false},
+		{"nested", "/user/profile/email", []string{"user", "profile", "email"}, false},
+		{"escaped slash", "/https:~1~1example.com~1roles", []string{"https://example.com/roles"}, false},
+		{"escaped tilde", "/a~0b", []string{"a~b"}, false},
+		{"escape order", "/~01", []string{"~1"}, false},
+		{"dots are literal", "/a.b", []string{"a.b"}, false},
+		{"empty token", "/", []string{""}, false},
+		{"empty pointer", "", nil, true},
+		{"missing leading slash", "sub", nil, true},
+		{"invalid escape", "/a~2", nil, true},
+		{"trailing tilde", "/a~", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			segments, err := parseJSONPointer(tt.pointer)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJSONPointer(%q) error = %v, wantErr %v", tt.pointer, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(segments) != len(tt.want) {
+				t.Fatalf("parseJSONPointer(%q) returned %d segments, want %d", tt.pointer, len(segments), len(tt.want))
+			}
+			for i, seg := range segments {
+				if seg.kind != segmentToken || seg.key != tt.want[i] {
+					t.Errorf("segment %d = %q, want %q", i, seg.key, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -2,16 +2,31 @@ package traefik_jwt_decoder_plugin
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 )
 
+// errClaimNotFound marks a path that does not resolve to a value.
+var errClaimNotFound = errors.New("claim not found")
+
 // ExtractClaim navigates a nested map using dot notation to extract a claim value.
-// Supports nested object navigation like "user.profile.email" to access deeply nested claims.
+// Supports nested object navigation like "user.profile.email" to access deeply nested claims,
+// and array selectors: "groups[0]", "roles[-1]", "orgs[*].id" and "addresses[0:2].city".
+// Keys containing dots can be escaped (`https://example\.com/roles`) or quoted
+// (`["https://example.com/roles"]`).
+// Filters keep matching array elements: "permissions[?(@.resource=='billing')].actions".
+//
+// Wildcards and slices collect the values reached through each selected element
+// into an array, so "orgs[*].id" yields ["org-1", "org-2"] and is formatted like any
+// other array claim. Elements the rest of the path does not match are skipped; a
+// projection that matches nothing counts as not found.
 //
 // The function enforces a maximum depth limit to prevent deep recursion attacks.
-// Each dot in the path represents one level of nesting.
+// Each key and each array selector in the path represents one level of nesting,
+// and paths inside filters add their own depth. Wildcards, slices and filters
+// may examine at most maxPathSelections array elements in total.
 //
 // Example:
 //   data := map[string]interface{}{
@@ -25,41 +40,65 @@ import (
 //   // Returns: "test@example.com", nil
 //
 // Returns an error if:
+//   - The path is malformed (see parseClaimPath)
 //   - Path depth exceeds maxDepth (DoS prevention)
-//   - Any intermediate path segment doesn't exist in the data
-//   - Any intermediate value is not an object (cannot navigate further)
+//   - The path selects more than maxPathSelections array elements (DoS prevention)
+//   - Any path segment doesn't exist in the data or an index is out of range
+//   - A key is applied to a non-object or a selector to a non-array
 func ExtractClaim(data map[string]interface{}, path string, maxDepth int) (interface{}, error) {
-	parts := strings.Split(path, ".")
+	segments, err := parseClaimPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return extractSegments(data, segments, path, maxDepth)
+}
 
+// ExtractClaimPointer extracts a claim addressed by an RFC 6901 JSON Pointer.
+// Pointers suit claim keys containing dots, such as namespaced custom claims.
+//
+// Example:
+//   data := map[string]interface{}{
+//       "https://example.com/roles": []interface{}{"admin"},
+//   }
+//   value, err := ExtractClaimPointer(data, "/https:~1~1example.com~1roles/0", 10)
+//   // Returns: "admin", nil
+//
+// Returns an error under the same conditions as ExtractClaim, or if the
+// pointer is malformed (see parseJSONPointer).
+func ExtractClaimPointer(data map[string]interface{}, pointer string, maxDepth int) (interface{}, error) {
+	segments, err := parseJSONPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return extractSegments(data, segments, pointer, maxDepth)
+}
+
+// extractSegments walks parsed segments after enforcing the depth limit.
+func extractSegments(data map[string]interface{}, segments []pathSegment, path string, maxDepth int) (interface{}, error) {
 	// Validate depth limit
-	if len(parts) > maxDepth {
+	if segmentsDepth(segments) > maxDepth {
 		return nil, fmt.Errorf("claim path depth exceeds maximum (%d)", maxDepth)
 	}
 
-	current := data
-
-	// Navigate through each part of the path
-	for i, part := range parts {
-		value, exists := current[part]
-		if !exists {
-			return nil, fmt.Errorf("claim not found: %s", path)
-		}
-
-		// If this is the last part, return the value
-		if i == len(parts)-1 {
-			return value, nil
-		}
-
-		// Otherwise, type assert to nested map
-		nested, ok := value.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid claim path: '%s' is not an object", part)
-		}
-
-		current = nested
+	value, err := newPathWalker().walk(data, segments, "")
+	if err == errClaimNotFound {
+		return nil, fmt.Errorf("claim not found: %s", path)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return value, nil
+}
 
-	return nil, fmt.Errorf("claim not found: %s", path)
+// arraySeparators maps the separator-based array formats to their separator.
+// "multi" collapses with commas wherever a single value is needed, such as
+// arrays nested inside an element.
+var arraySeparators = map[string]string{
+	"":          ", ",
+	"comma":     ", ",
+	"space":     " ",
+	"semicolon": "; ",
+	"multi":     ", ",
 }
 
 // ConvertClaimToString converts a JWT claim value to a string representation.
@@ -68,6 +107,7 @@ func ExtractClaim(data map[string]interface{}, path string, maxDepth int) (inter
 // Supported types:
 //   - string: Returned as-is
 //   - bool: Converted to "true" or "false"
+//   - json.Number: Returned exactly as written in the token
 //   - float64: Converted to string without scientific notation
 //   - int: Converted to string
 //   - []interface{} (arrays): Formatted based on arrayFormat parameter
@@ -76,7 +116,11 @@ func ExtractClaim(data map[string]interface{}, path string, maxDepth int) (inter
 //
 // Array Formatting:
 //   - "comma" (default): ["admin", "user"] → "admin, user"
+//   - "space": ["admin", "user"] → "admin user"
+//   - "semicolon": ["admin", "user"] → "admin; user"
 //   - "json": ["admin", "user"] → "[\"admin\",\"user\"]"
+//   - "sf-list": ["admin", "user"] → "\"admin\", \"user\"" (RFC 8941 list; scalars become one-item lists)
+//   - "multi": formatted like "comma"; use ConvertClaimToStrings for one value per element
 //
 // Example:
 //   // String claim
@@ -96,11 +140,60 @@ func ExtractClaim(data map[string]interface{}, path string, maxDepth int) (inter
 //   str, _ := ConvertClaimToString(obj, "comma")
 //   // Returns: "{\"tenant_id\":\"123\"}"
 func ConvertClaimToString(value interface{}, arrayFormat string) (string, error) {
+	return convertClaim(value, arrayFormat, arraySeparators[arrayFormat])
+}
+
+// ConvertClaimToStrings converts a claim to one or more header values.
+// With arrayFormat "multi", each array element becomes its own value (nested
+// arrays and objects are formatted like "comma"); every other format yields a
+// single value. separator is used by the "separator" format.
+//
+// Example:
+//   values, _ := ConvertClaimToStrings([]interface{}{"admin", "a,b"}, "multi", "")
+//   // Returns: ["admin", "a,b"]
+//
+//   values, _ := ConvertClaimToStrings([]interface{}{"admin", "user"}, "separator", " | ")
+//   // Returns: ["admin | user"]
+func ConvertClaimToStrings(value interface{}, arrayFormat, separator string) ([]string, error) {
+	if arr, ok := value.([]interface{}); ok && arrayFormat == "multi" {
+		values := make([]string, 0, len(arr))
+		for _, elem := range arr {
+			s, err := ConvertClaimToString(elem, arrayFormat)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	}
+
+	if arrayFormat != "separator" {
+		separator = arraySeparators[arrayFormat]
+	}
+	s, err := convertClaim(value, arrayFormat, separator)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// convertClaim implements ConvertClaimToString, joining separator-based
+// array formats with separator.
+func convertClaim(value interface{}, arrayFormat, separator string) (string, error) {
 	// Handle nil
 	if value == nil {
 		return "", nil
 	}
 
+	// Structured field lists are always lists, even for scalar claims
+	if arrayFormat == "sf-list" {
+		arr, ok := value.([]interface{})
+		if !ok {
+			arr = []interface{}{value}
+		}
+		return formatStructuredList(arr)
+	}
+
 	// Type switch for different claim types
 	switch v := value.(type) {
 	case string:
@@ -109,6 +202,10 @@ func ConvertClaimToString(value interface{}, arrayFormat string) (string, error)
 	case bool:
 		return strconv.FormatBool(v), nil
 
+	case json.Number:
+		// Exact text from the token, e.g. 64-bit IDs beyond float64 precision
+		return v.String(), nil
+
 	case float64:
 		return strconv.FormatFloat(v, 'f', -1, 64), nil
 
@@ -124,17 +221,16 @@ func ConvertClaimToString(value interface{}, arrayFormat string) (string, error)
 			}
 			return string(jsonBytes), nil
 		}
-
-		// Default to comma-separated format
+		// Separator-based formats
 		var parts []string
 		for _, elem := range v {
-			elemStr, err := ConvertClaimToString(elem, arrayFormat)
+			elemStr, err := convertClaim(elem, arrayFormat, separator)
 			if err != nil {
 				return "", err
 			}
 			parts = append(parts, elemStr)
 		}
-		return strings.Join(parts, ", "), nil
+		return strings.Join(parts, separator), nil
 
 	case map[string]interface{}:
 		// Marshal objects to JSON
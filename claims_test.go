@@ -1,6 +1,8 @@
 package traefik_jwt_decoder_plugin
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -249,6 +251,166 @@ func TestExtractClaim_InvalidPath(t *testing.T) {
 	}
 }
 
+// TestExtractClaim_ArraySelectors verifies index, wildcard and slice selectors
+func TestExtractClaim_ArraySelectors(t *testing.T) {
+	data := map[string]interface{}{
+		"groups": []interface{}{"engineering", "admins", "oncall"},
+		"orgs": []interface{}{
+			map[string]interface{}{"id": "org-1", "name": "Acme"},
+			map[string]interface{}{"name": "No ID"},
+			map[string]interface{}{"id": "org-3"},
+		},
+		"addresses": []interface{}{
+			map[string]interface{}{"city": "Berlin"},
+			map[string]interface{}{"city": "Paris"},
+			map[string]interface{}{"city": "Rome"},
+		},
+		"matrix": []interface{}{
+			[]interface{}{float64(1), float64(2)},
+			[]interface{}{float64(3), float64(4)},
+		},
+		"empty": []interface{}{},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{"first element", "groups[0]", "engineering", false},
+		{"last element", "groups[-1]", "oncall", false},
+		{"index into object", "orgs[0].name", "Acme", false},
+		{"wildcard skips unmatched elements", "orgs[*].id", []interface{}{"org-1", "org-3"}, false},
+		{"wildcard over scalars", "groups[*]", []interface{}{"engineering", "admins", "oncall"}, false},
+		{"slice", "addresses[0:2].city", []interface{}{"Berlin", "Paris"}, false},
+		{"negative slice", "addresses[-1:].city", []interface{}{"Rome"}, false},
+		{"chained selectors", "matrix[1][0]", float64(3), false},
+		{"nested wildcards", "matrix[*][-1]", []interface{}{float64(2), float64(4)}, false},
+		{"index out of range", "groups[3]", nil, true},
+		{"negative index out of range", "groups[-4]", nil, true},
+		{"wildcard on empty array", "empty[*]", nil, true},
+		{"wildcard matches nothing", "orgs[*].missing", nil, true},
+		{"index on object", "orgs[0][0]", nil, true},
+		{"index on string", "groups[0][0]", nil, true},
+		{"key on array", "groups.name", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ExtractClaim(data, tt.path, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractClaim(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("ExtractClaim(%q) = %#v, want %#v", tt.path, value, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractClaim_SelectorDepth verifies array selectors count toward maxDepth
+func TestExtractClaim_SelectorDepth(t *testing.T) {
+	data := map[string]interface{}{
+		"orgs": []interface{}{map[string]interface{}{"id": "org-1"}},
+	}
+
+	if _, err := ExtractClaim(data, "orgs[*].id", 3); err != nil {
+		t.Errorf("ExtractClaim() depth 3 with max 3 unexpected error: %v", err)
+	}
+	if _, err := ExtractClaim(data, "orgs[*].id", 2); err == nil {
+		t.Error("ExtractClaim() depth 3 with max 2 expected error, got nil")
+	}
+}
+
+// TestExtractClaim_NamespacedKeys verifies escaped and quoted keys containing dots
+func TestExtractClaim_NamespacedKeys(t *testing.T) {
+	data := map[string]interface{}{
+		"https://example.com/roles": []interface{}{"admin", "billing"},
+		"https://example.com/claims": map[string]interface{}{
+			"tenant": "acme",
+		},
+		"cognito:groups": []interface{}{"ops"},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want interface{}
+	}{
+		{"escaped dots", `https://example\.com/roles`, []interface{}{"admin", "billing"}},
+		{"quoted key", `["https://example.com/roles"]`, []interface{}{"admin", "billing"}},
+		{"quoted key with index", `["https://example.com/roles"][-1]`, "billing"},
+		{"quoted key then nested", `['https://example.com/claims'].tenant`, "acme"},
+		{"colon key", "cognito:groups[0]", "ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ExtractClaim(data, tt.path, 10)
+			if err != nil {
+				t.Fatalf("ExtractClaim(%q) error: %v", tt.path, err)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("ExtractClaim(%q) = %#v, want %#v", tt.path, value, tt.want)
+			}
+		})
+	}
+
+	// Without escaping, the dots split the key
+	if _, err := ExtractClaim(data, "https://example.com/roles", 10); err == nil {
+		t.Error("ExtractClaim() with unescaped dots expected error, got nil")
+	}
+}
+
+// TestExtractClaimPointer verifies JSON Pointer navigation of objects and arrays
+func TestExtractClaimPointer(t *testing.T) {
+	data := map[string]interface{}{
+		"https://example.com/roles": []interface{}{"admin", "billing"},
+		"user": map[string]interface{}{
+			"profile": map[string]interface{}{"email": "test@example.com"},
+			"0":       "zero key",
+		},
+		"a~b": "tilde",
+	}
+
+	tests := []struct {
+		name    string
+		pointer string
+		want    interface{}
+		wantErr bool
+	}{
+		{"namespaced key", "/https:~1~1example.com~1roles", []interface{}{"admin", "billing"}, false},
+		{"array index", "/https:~1~1example.com~1roles/1", "billing", false},
+		{"nested", "/user/profile/email", "test@example.com", false},
+		{"numeric object key", "/user/0", "zero key", false},
+		{"tilde key", "/a~0b", "tilde", false},
+		{"index out of range", "/https:~1~1example.com~1roles/2", nil, true},
+		{"past-the-end index", "/https:~1~1example.com~1roles/-", nil, true},
+		{"leading zero index", "/https:~1~1example.com~1roles/01", nil, true},
+		{"negative index", "/https:~1~1example.com~1roles/-1", nil, true},
+		{"token on string", "/a~0b/x", nil, true},
+		{"missing key", "/user/missing", nil, true},
+		{"malformed", "user/profile", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ExtractClaimPointer(data, tt.pointer, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractClaimPointer(%q) error = %v, wantErr %v", tt.pointer, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(value, tt.want) {
+				t.Errorf("ExtractClaimPointer(%q) = %#v, want %#v", tt.pointer, value, tt.want)
+			}
+		})
+	}
+
+	if _, err := ExtractClaimPointer(data, "/user/profile/email", 2); err == nil {
+		t.Error("ExtractClaimPointer() depth 3 with max 2 expected error, got nil")
+	}
+}
+
 // TestExtractClaim_NilValue verifies handling of nil values in claims
 func TestExtractClaim_NilValue(t *testing.T) {
 	data := map[string]interface{}{
@@ -301,6 +463,19 @@ func TestConvertClaimToString_String(t *testing.T) {
 	}
 }
 
+// TestConvertClaimToString_Number verifies json.Number claims keep their exact text
+func TestConvertClaimToString_Number(t *testing.T) {
+	for _, text := range []string{"9007199254740993", "1.50", "1e3", "-0"} {
+		result, err := ConvertClaimToString(json.Number(text), "")
+		if err != nil {
+			t.Fatalf("ConvertClaimToString() error: %v", err)
+		}
+		if result != text {
+			t.Errorf("ConvertClaimToString(json.Number(%q)) = %q, want exact text", text, result)
+		}
+	}
+}
+
 // TestConvertClaimToString_Int verifies integer conversion
 func TestConvertClaimToString_Int(t *testing.T) {
 	result, err := ConvertClaimToString(42, "")
@@ -529,3 +704,66 @@ func TestConvertClaimToString_Object(t *testing.T) {
 		})
 	}
 }
+
+// TestConvertClaimToString_ArrayFormats verifies separator and structured field formats
+func TestConvertClaimToString_ArrayFormats(t *testing.T) {
+	roles := []interface{}{"admin", "user"}
+
+	tests := []struct {
+		name   string
+		value  interface{}
+		format string
+		want   string
+	}{
+		{"space", roles, "space", "admin user"},
+		{"semicolon", roles, "semicolon", "admin; user"},
+		{"multi collapses", roles, "multi", "admin, user"},
+		{"sf-list", roles, "sf-list", `"admin", "user"`},
+		{"sf-list scalar", "admin", "sf-list", `"admin"`},
+		{"sf-list nil", nil, "sf-list", ""},
+		{"space scalar", "admin", "space", "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertClaimToString(tt.value, tt.format)
+			if err != nil {
+				t.Fatalf("ConvertClaimToString() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConvertClaimToString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConvertClaimToStrings verifies per-element values for "multi" and custom separators
+func TestConvertClaimToStrings(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     interface{}
+		format    string
+		separator string
+		want      []string
+	}{
+		{"multi", []interface{}{"admin", "billing, eu"}, "multi", "", []string{"admin", "billing, eu"}},
+		{"multi nested", []interface{}{"a", []interface{}{"b", "c"}, map[string]interface{}{"d": "e"}}, "multi", "", []string{"a", "b, c", `{"d":"e"}`}},
+		{"multi scalar", "admin", "multi", "", []string{"admin"}},
+		{"multi empty", []interface{}{}, "multi", "", []string{}},
+		{"separator", []interface{}{"admin", "user"}, "separator", " | ", []string{"admin | user"}},
+		{"separator ignored by other formats", []interface{}{"admin", "user"}, "comma", " | ", []string{"admin, user"}},
+		{"json", []interface{}{"admin"}, "json", "", []string{`["admin"]`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertClaimToStrings(tt.value, tt.format, tt.separator)
+			if err != nil {
+				t.Fatalf("ConvertClaimToStrings() error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertClaimToStrings() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,13 @@
 package traefik_jwt_decoder_plugin
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
+	"time"
+	"unicode"
 )
 
 // Config holds the complete plugin configuration with all settings for
@@ -34,6 +39,31 @@ type Config struct {
 	// Useful for preventing JWT exposure to upstream services
 	RemoveSourceHeader bool `json:"removeSourceHeader,omitempty" yaml:"removeSourceHeader,omitempty"`
 
+	// StripIncomingHeaders deletes every configured headerName, including the
+	// payloadHeader's, from the inbound request before the token is read,
+	// whether or not a token is present (default: false). Prevents clients
+	// from spoofing identity headers
+	StripIncomingHeaders bool `json:"stripIncomingHeaders,omitempty" yaml:"stripIncomingHeaders,omitempty"`
+
+	// StripHeaderPrefix additionally strips inbound headers starting with this
+	// prefix, compared case-insensitively (e.g., "X-Jwt-") (default: none)
+	// Requires StripIncomingHeaders; protected headers are never stripped
+	StripHeaderPrefix string `json:"stripHeaderPrefix,omitempty" yaml:"stripHeaderPrefix,omitempty"`
+
+	// ProtectedHeaders lists additional headers claims may never be injected
+	// into (e.g., ["X-Internal-Auth"]), added to the built-in list of proxy,
+	// credential, framing and hop-by-hop headers (default: none)
+	ProtectedHeaders []string `json:"protectedHeaders,omitempty" yaml:"protectedHeaders,omitempty"`
+
+	// ReplaceProtectedHeaders uses ProtectedHeaders instead of the built-in
+	// list (default: false). Only for deployments that must inject a built-in
+	// protected header; the replacement list must not be empty
+	ReplaceProtectedHeaders bool `json:"replaceProtectedHeaders,omitempty" yaml:"replaceProtectedHeaders,omitempty"`
+
+	// AllowedHeaderPatterns restricts every headerName to names matching one of
+	// these case-insensitive globs (e.g., ["X-Auth-*"]) (default: any name)
+	AllowedHeaderPatterns []string `json:"allowedHeaderPatterns,omitempty" yaml:"allowedHeaderPatterns,omitempty"`
+
 	// MaxClaimDepth is the maximum depth for nested claim paths (default: 10)
 	// Prevents deep recursion attacks
 	MaxClaimDepth int `json:"maxClaimDepth,omitempty" yaml:"maxClaimDepth,omitempty"`
@@ -57,14 +87,172 @@ type Config struct {
 	// LogMissingClaims controls whether to log when claims are not found (default: false)
 	// Set to true for debugging, false for production to reduce log noise
 	LogMissingClaims bool `json:"logMissingClaims,omitempty" yaml:"logMissingClaims,omitempty"`
+
+	// RequiredClaims lists claim paths that must be present in the token,
+	// whether or not they are mapped to headers (default: none)
+	// Only enforced when continueOnError is false; otherwise logged
+	RequiredClaims []string `json:"requiredClaims,omitempty" yaml:"requiredClaims,omitempty"`
+
+	// Rules are claim assertions that must all pass, otherwise the request is
+	// rejected with 403 Forbidden regardless of continueOnError (default: none)
+	Rules []Rule `json:"rules,omitempty" yaml:"rules,omitempty"`
+
+	// Policies apply rules to requests matching a method and path; every
+	// matching policy must pass or the request is rejected with 403 Forbidden
+	Policies []Policy `json:"policies,omitempty" yaml:"policies,omitempty"`
+
+	// ScopeClaims are the claims OAuth2 scopes are read from and merged
+	// (default: ["scope", "scp"])
+	ScopeClaims []string `json:"scopeClaims,omitempty" yaml:"scopeClaims,omitempty"`
+
+	// RequiredScopes must all be granted by the token (default: none)
+	// Failures return 403 with an RFC 6750 insufficient_scope error
+	RequiredScopes []string `json:"requiredScopes,omitempty" yaml:"requiredScopes,omitempty"`
+
+	// RequiredAnyScopes requires at least one of the listed scopes (default: none)
+	RequiredAnyScopes []string `json:"requiredAnyScopes,omitempty" yaml:"requiredAnyScopes,omitempty"`
+
+	// ValidateTimeClaims enables validation of exp, nbf and iat (default: false)
+	// Tokens failing validation never produce headers
+	ValidateTimeClaims bool `json:"validateTimeClaims,omitempty" yaml:"validateTimeClaims,omitempty"`
+
+	// ClockSkew is the leeway applied to time claim checks, e.g. "30s" (default: "0s")
+	ClockSkew string `json:"clockSkew,omitempty" yaml:"clockSkew,omitempty"`
+
+	// MaxTokenAge rejects tokens whose iat is older than this duration, e.g. "24h"
+	// Requires the iat claim when set (default: no limit)
+	MaxTokenAge string `json:"maxTokenAge,omitempty" yaml:"maxTokenAge,omitempty"`
+
+	// AllowedIssuers lists accepted 'iss' values, compared exactly (default: any)
+	AllowedIssuers []string `json:"allowedIssuers,omitempty" yaml:"allowedIssuers,omitempty"`
+
+	// AllowedAudiences lists accepted 'aud' values; a token is accepted when
+	// any of its audiences is listed (default: any)
+	AllowedAudiences []string `json:"allowedAudiences,omitempty" yaml:"allowedAudiences,omitempty"`
+
+	// AllowedAlgorithms restricts the JWT 'alg' header to the listed values (default: any)
+	// "none" is always rejected when verification is enabled
+	AllowedAlgorithms []string `json:"allowedAlgorithms,omitempty" yaml:"allowedAlgorithms,omitempty"`
+
+	// Verification configures optional JWT signature verification (default: disabled)
+	// When enabled, tokens whose signature does not verify never produce headers
+	Verification VerificationConfig `json:"verification,omitempty" yaml:"verification,omitempty"`
+
+	// PayloadHeader injects the whole decoded claim set as a single header
+	// (default: disabled)
+	PayloadHeader PayloadHeaderConfig `json:"payloadHeader,omitempty" yaml:"payloadHeader,omitempty"`
+}
+
+// PayloadHeaderConfig holds the settings for injecting the full JWT payload
+// as one header, similar to Istio's outputPayloadToHeader.
+type PayloadHeaderConfig struct {
+	// HeaderName is the header the payload is written to (e.g., "X-Jwt-Payload")
+	// Setting it enables the feature
+	HeaderName string `json:"headerName,omitempty" yaml:"headerName,omitempty"`
+
+	// Format is the header value encoding:
+	//   - "base64" (default): base64url-encoded JSON without padding
+	//   - "json": the JSON document as-is
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Claims limits the payload to these top-level claim names (default: all claims)
+	Claims []string `json:"claims,omitempty" yaml:"claims,omitempty"`
+
+	// IncludeHeader emits {"header": ..., "payload": ...} so the JWT header
+	// (alg, kid, typ, ...) is included as well (default: false)
+	IncludeHeader bool `json:"includeHeader,omitempty" yaml:"includeHeader,omitempty"`
+
+	// Override determines behavior when the header already exists (default: false, preserve)
+	Override bool `json:"override,omitempty" yaml:"override,omitempty"`
+}
+
+// VerificationConfig holds the settings for JWT signature verification.
+type VerificationConfig struct {
+	// Enabled turns on signature verification (default: false)
+	Enabled bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+
+	// Keys is the list of keys a token signature may be verified against
+	// At least one key or a jwksFile is required when verification is enabled
+	Keys []KeyConfig `json:"keys,omitempty" yaml:"keys,omitempty"`
+
+	// JWKSFile is a path to a JSON Web Key Set document loaded at startup
+	// Keys are selected per request by the JWT 'kid' header
+	JWKSFile string `json:"jwksFile,omitempty" yaml:"jwksFile,omitempty"`
+
+	// JWKSURL is an HTTP(S) endpoint serving a JSON Web Key Set
+	// The document is fetched in the background and cached
+	JWKSURL string `json:"jwksURL,omitempty" yaml:"jwksURL,omitempty"`
+
+	// JWKSCacheTTL is how long fetched keys are cached when the response has
+	// no Cache-Control max-age (default: "1h")
+	JWKSCacheTTL string `json:"jwksCacheTTL,omitempty" yaml:"jwksCacheTTL,omitempty"`
+
+	// JWKSFetchTimeout bounds both the HTTP fetch and how long a request may
+	// wait for keys on a cold cache (default: "5s")
+	JWKSFetchTimeout string `json:"jwksFetchTimeout,omitempty" yaml:"jwksFetchTimeout,omitempty"`
+
+	// JWKSMinRefetchInterval is the minimum time between fetches, limiting
+	// on-demand refetches triggered by unknown 'kid' values (default: "30s")
+	JWKSMinRefetchInterval string `json:"jwksMinRefetchInterval,omitempty" yaml:"jwksMinRefetchInterval,omitempty"`
+}
+
+// Default remote JWKS cache settings, used when the corresponding option is empty.
+const (
+	defaultJWKSCacheTTL           = "1h"
+	defaultJWKSFetchTimeout       = "5s"
+	defaultJWKSMinRefetchInterval = "30s"
+)
+
+// KeyConfig defines a single verification key, provided inline or by file path.
+type KeyConfig struct {
+	// KeyID is an optional identifier for the key (matched against JWT 'kid')
+	KeyID string `json:"kid,omitempty" yaml:"kid,omitempty"`
+
+	// Algorithm optionally binds the key to a single JWS algorithm (e.g. "RS256")
+	// Must match the key type; keys are always limited to their own algorithm family
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+
+	// Secret is an inline HMAC shared secret (HS256, HS384, HS512)
+	// Exactly one of Secret, SecretFile, PublicKey or PublicKeyFile must be set
+	Secret string `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// SecretFile is a path to a file containing the HMAC shared secret
+	// Trailing newlines in the file are ignored
+	SecretFile string `json:"secretFile,omitempty" yaml:"secretFile,omitempty"`
+
+	// PublicKey is an inline PEM-encoded public key or certificate (RS*, PS*, ES*, EdDSA)
+	// RSA keys must be at least 2048 bits
+	PublicKey string `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+
+	// PublicKeyFile is a path to a PEM-encoded public key or certificate
+	PublicKeyFile string `json:"publicKeyFile,omitempty" yaml:"publicKeyFile,omitempty"`
 }
 
 // ClaimMapping defines a single mapping from a JWT claim path to an HTTP header name.
 type ClaimMapping struct {
 	// ClaimPath is the path to the claim using dot notation (e.g., "user.profile.email")
-	// Required field
+	// Array selectors are supported: "groups[0]", "roles[-1]", "orgs[*].id", "addresses[0:2].city"
+	// Keys containing dots can be escaped or quoted: `["https://example.com/roles"]`
+	// Required unless Template is set
 	ClaimPath string `json:"claimPath" yaml:"claimPath"`
 
+	// Template builds the header value from several claims with Go text/template,
+	// as an alternative to ClaimPath (e.g., "tenant={{.payload.tenant}};user={{.payload.sub}}")
+	// Sections are available as .header and .payload; helpers: join, lower, default, json, b64
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+
+	// PathSyntax selects how ClaimPath and FallbackPaths are parsed: "dot" or "pointer" (default: "dot")
+	// "pointer" treats them as RFC 6901 JSON Pointers: "/https:~1~1example.com~1roles"
+	PathSyntax string `json:"pathSyntax,omitempty" yaml:"pathSyntax,omitempty"`
+
+	// FallbackPaths are tried in order when ClaimPath is missing (default: none)
+	// e.g. ["preferred_username", "upn"] after a ClaimPath of "email"
+	FallbackPaths []string `json:"fallbackPaths,omitempty" yaml:"fallbackPaths,omitempty"`
+
+	// Default is injected as-is when neither ClaimPath nor any fallback is found
+	// (default: none, the header is skipped). Transforms are not applied to it
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+
 	// HeaderName is the target HTTP header name (e.g., "X-User-Email")
 	// Required field
 	HeaderName string `json:"headerName" yaml:"headerName"`
@@ -76,8 +264,132 @@ type ClaimMapping struct {
 
 	// ArrayFormat specifies how to format array claims:
 	//   - "comma" (default): ["admin", "user"] → "admin, user"
+	//   - "space": ["admin", "user"] → "admin user"
+	//   - "semicolon": ["admin", "user"] → "admin; user"
+	//   - "separator": joined with ArraySeparator
 	//   - "json": ["admin", "user"] → "[\"admin\",\"user\"]"
+	//   - "sf-list": ["admin", "user"] → "\"admin\", \"user\"" (RFC 8941 structured field list)
+	//   - "multi": one header line per element (Header.Add)
 	ArrayFormat string `json:"arrayFormat,omitempty" yaml:"arrayFormat,omitempty"`
+
+	// ArraySeparator joins array elements when ArrayFormat is "separator" (e.g., " | ")
+	ArraySeparator string `json:"arraySeparator,omitempty" yaml:"arraySeparator,omitempty"`
+
+	// NumberFormat formats numeric claims and array elements (default: exact token text):
+	//   - "integer": 1e3 → "1000"; a number with a fraction skips the header
+	//   - "fixed": rounded to Decimals fractional digits, e.g. 2.5 → "2.50"
+	//   - "rfc3339": epoch seconds as UTC, e.g. 1700000000 → "2023-11-14T22:13:20Z"
+	NumberFormat string `json:"numberFormat,omitempty" yaml:"numberFormat,omitempty"`
+
+	// Decimals is the number of fractional digits for NumberFormat "fixed" (default: 0, max: 18)
+	Decimals int `json:"decimals,omitempty" yaml:"decimals,omitempty"`
+
+	// Encoding encodes the final header value for backends that mishandle UTF-8:
+	//   - "raw" (default): UTF-8 bytes as-is
+	//   - "percent": "José" → "Jos%C3%A9" (non-ASCII, control characters and '%')
+	//   - "base64": standard base64 of the UTF-8 bytes
+	//   - "rfc8187": "José" → "UTF-8''Jos%C3%A9" (RFC 8187 ext-value)
+	//   - "ascii-fold": "José Müller" → "Jose Muller"; unknown characters become '?'
+	// Applied to each line for arrayFormat "multi" and to defaults and templates
+	Encoding string `json:"encoding,omitempty" yaml:"encoding,omitempty"`
+
+	// Scopes treats the claim as an OAuth2 scope list (default: false)
+	// A space-delimited string is split into scopes, so "read write" is
+	// formatted like the array ["read", "write"] according to ArrayFormat
+	Scopes bool `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+
+	// Transforms are applied in order to the string value before injection
+	// (default: none). A failing transform skips the header.
+	Transforms []Transform `json:"transforms,omitempty" yaml:"transforms,omitempty"`
+
+	// Required fails the request when the claim is missing or cannot be
+	// converted to a header value (default: false)
+	// Only enforced when continueOnError is false; otherwise logged
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// Transform is one step of a ClaimMapping's value transformation pipeline.
+type Transform struct {
+	// Type is the transformation to apply:
+	//   - "lower": lowercase the value
+	//   - "stripPrefix": remove Value from the start of the value if present
+	//   - "base64": standard base64 encoding (e.g. for JSON object claims)
+	//   - "truncate": keep at most Length characters
+	//   - "regex": replace the value with capture group Group of the regex in Value
+	//   - "lookup": replace the value with its entry in Table
+	// Required field
+	Type string `json:"type" yaml:"type"`
+
+	// Value is the prefix for "stripPrefix" or the pattern for "regex"
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Length is the maximum number of characters kept by "truncate"
+	Length int `json:"length,omitempty" yaml:"length,omitempty"`
+
+	// Group is the "regex" capture group to keep
+	// (default: 1, or the whole match when the pattern has no groups)
+	// A value that does not match skips the header
+	Group int `json:"group,omitempty" yaml:"group,omitempty"`
+
+	// Table maps claim values to replacements for "lookup"
+	Table map[string]string `json:"table,omitempty" yaml:"table,omitempty"`
+
+	// Default replaces values missing from Table (default: none, the header is skipped)
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Rule is a claim assertion evaluated after the token is parsed.
+// A request whose token fails any rule is rejected with 403 Forbidden.
+type Rule struct {
+	// ClaimPath is the path to the claim using dot notation (e.g., "tenant.status")
+	// Required field
+	ClaimPath string `json:"claimPath" yaml:"claimPath"`
+
+	// Operator is the assertion to apply:
+	//   - "exists": the claim is present
+	//   - "equals", "notEquals": exact string comparison
+	//   - "in", "notIn": the claim equals one of Values
+	//   - "contains": array claim has an element equal to Value, or string claim contains Value
+	//   - "prefix": the claim starts with Value
+	//   - "regex": the claim matches the regular expression in Value
+	//   - "gt", "gte", "lt", "lte": numeric comparison against Value
+	// For array claims, other operators pass when any element matches
+	// Required field
+	Operator string `json:"operator" yaml:"operator"`
+
+	// Value is the operand for single-value operators
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Values is the operand list for "in" and "notIn"
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+
+	// Message is returned in the 403 response body when the rule fails
+	// (default: "access denied")
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// Policy applies claim rules to requests matching a method and path.
+// Every matching policy must pass, otherwise the request is rejected with
+// 403 Forbidden.
+type Policy struct {
+	// Methods restricts the policy to these HTTP methods, e.g. ["DELETE"] (default: all)
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+
+	// Path is a glob matched against the request path, e.g. "/admin/*"
+	// Uses path.Match semantics, except that a trailing "/*" or "/**" covers
+	// the path before it and everything under it ("/admin", "/admin/x/y")
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// PathPrefix matches request paths starting with this prefix, e.g. "/reports"
+	// Mutually exclusive with Path; when neither is set the policy matches all paths
+	PathPrefix string `json:"pathPrefix,omitempty" yaml:"pathPrefix,omitempty"`
+
+	// Rules are the claim requirements for matching requests (at least one)
+	Rules []Rule `json:"rules" yaml:"rules"`
+
+	// Message is returned when a rule without its own message fails
+	// (default: "access denied")
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
 }
 
 // CreateConfig creates and initializes the plugin configuration with default values.
@@ -95,77 +407,253 @@ func CreateConfig() *Config {
 		LogLevel:           "warn",
 		StrictMode:         false,
 		LogMissingClaims:   false,
+		Verification: VerificationConfig{
+			JWKSCacheTTL:           defaultJWKSCacheTTL,
+			JWKSFetchTimeout:       defaultJWKSFetchTimeout,
+			JWKSMinRefetchInterval: defaultJWKSMinRefetchInterval,
+		},
 	}
 }
 
+// arrayFormats lists the supported ClaimMapping.ArrayFormat values.
+var arrayFormats = map[string]bool{
+	"comma":     true,
+	"space":     true,
+	"semicolon": true,
+	"separator": true,
+	"json":      true,
+	"sf-list":   true,
+	"multi":     true,
+}
+
 // Validate checks the configuration for errors and enforces business rules.
 // Called during plugin initialization to ensure configuration is valid before
 // processing any requests.
 //
 // Validation Rules:
-//   - Claims array must not be empty
-//   - Each ClaimMapping must have non-empty claimPath and headerName
-//   - ArrayFormat must be "", "comma", or "json"
-//   - No duplicate headerName values (case-insensitive)
+//   - Claims array must not be empty unless payloadHeader is configured
+//   - Each ClaimMapping must have a headerName and exactly one of claimPath and template
+//   - Templates must parse and cannot be combined with pathSyntax, scopes, required, fallbackPaths, default or numberFormat
+//   - FallbackPaths must be well-formed; required and default are mutually exclusive
+//   - Claim paths must be well-formed (keys, [n], [*] and [start:end] selectors)
+//   - PathSyntax must be "", "dot", or "pointer"; pointer paths must start with '/'
+//   - Transforms must have a known type, required operands and a valid regex
+//   - ArrayFormat must be "", "comma", "space", "semicolon", "separator", "json", "sf-list", or "multi"
+//   - ArraySeparator is required by, and only valid with, "separator"; no control characters
+//   - NumberFormat must be "", "integer", "fixed", or "rfc3339"; decimals (0-18) only with "fixed"
+//   - Encoding must be "", "raw", "percent", "base64", "rfc8187", or "ascii-fold"
+//   - HeaderName must be an RFC 7230 token (no spaces, colons or other separators)
+//   - No duplicate headerName values, compared case-insensitively with '_' treated as '-'
+//   - No headerName may be a protected header; with allowedHeaderPatterns, each must match a pattern
+//   - ProtectedHeaders and AllowedHeaderPatterns must not contain empty values; patterns must be valid globs
+//   - ReplaceProtectedHeaders requires a non-empty protectedHeaders list
+//   - StripHeaderPrefix requires stripIncomingHeaders and must not match sourceHeader
+//   - With stripIncomingHeaders, no headerName may equal sourceHeader
 //   - Sections must contain only "header" or "payload"
 //   - Sections array must not be empty
 //   - MaxClaimDepth must be greater than 0
 //   - MaxHeaderSize must be greater than 0
+//   - ClockSkew must be a non-negative duration, MaxTokenAge a positive one
+//   - RequiredClaims must not contain empty paths
+//   - Rules must have a claimPath, a known operator and a valid operand
+//   - Policies must have valid path patterns and at least one valid rule
+//   - ScopeClaims, RequiredScopes and RequiredAnyScopes must not contain empty or whitespace values
+//   - AllowedIssuers and AllowedAudiences must not contain empty values
+//   - AllowedAlgorithms must name supported algorithms ('none' only without verification)
+//   - Verification, if enabled, must have at least one loadable key, jwksFile or jwksURL
+//   - jwksURL must be http(s) and JWKS durations must be positive
+//   - PayloadHeader settings require its headerName, which follows the headerName rules;
+//     format must be "", "base64", or "json" and claims must not contain empty values
 //
-// Returns descriptive error if any validation rule is violated.
+// Every violated rule is reported: the returned error joins one descriptive
+// error per problem (see errors.Join), or is nil when the configuration is valid.
 func (c *Config) Validate() error {
-	// Check Claims array not empty
-	if len(c.Claims) == 0 {
-		return fmt.Errorf("claims array cannot be empty")
+	var errs []error
+
+	// Check Claims array not empty; a payload header alone is enough
+	if len(c.Claims) == 0 && c.PayloadHeader.HeaderName == "" {
+		errs = append(errs, fmt.Errorf("claims array cannot be empty"))
 	}
 
-	// Track header names for duplicate detection (case-insensitive)
-	headerNames := make(map[string]bool)
+	// Protected headers and allowed patterns must be usable before the
+	// mappings are checked against them
+	for _, name := range c.ProtectedHeaders {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, fmt.Errorf("protectedHeaders cannot contain empty values"))
+		}
+	}
+	if c.ReplaceProtectedHeaders && len(c.ProtectedHeaders) == 0 {
+		errs = append(errs, fmt.Errorf("replaceProtectedHeaders requires a non-empty protectedHeaders list"))
+	}
+	for _, pattern := range c.AllowedHeaderPatterns {
+		if strings.TrimSpace(pattern) == "" {
+			errs = append(errs, fmt.Errorf("allowedHeaderPatterns cannot contain empty values"))
+		} else if _, err := path.Match(pattern, ""); err != nil {
+			errs = append(errs, fmt.Errorf("invalid allowedHeaderPatterns entry '%s': %v", pattern, err))
+		}
+	}
+	protected := protectedHeaderSet(c.ProtectedHeaders, c.ReplaceProtectedHeaders)
+
+	// Track normalized header names for collision detection
+	headerNames := make(map[string]string)
+
+	// checkHeaderName validates a header name this plugin injects; prefix
+	// identifies the setting in error messages
+	checkHeaderName := func(prefix, name string) {
+		// HeaderName must be a valid RFC 7230 token
+		if !isHeaderToken(name) {
+			errs = append(errs, fmt.Errorf("%s: invalid headerName '%s', must be an RFC 7230 token", prefix, name))
+		}
+
+		// HeaderName must not target a protected header and must match the allow-list
+		if protected[strings.ToLower(name)] {
+			errs = append(errs, fmt.Errorf("%s: headerName '%s' is a protected header", prefix, name))
+		}
+		if len(c.AllowedHeaderPatterns) > 0 && !matchesHeaderPattern(name, c.AllowedHeaderPatterns) {
+			errs = append(errs, fmt.Errorf("%s: headerName '%s' does not match allowedHeaderPatterns", prefix, name))
+		}
+
+		// Check for names that collide once proxies normalize them
+		normalized := normalizeHeaderName(name)
+		if previous, ok := headerNames[normalized]; ok {
+			if strings.EqualFold(previous, name) {
+				errs = append(errs, fmt.Errorf("duplicate headerName: %s", name))
+			} else {
+				errs = append(errs, fmt.Errorf("%s: headerName '%s' collides with '%s' after normalization", prefix, name, previous))
+			}
+			return
+		}
+		headerNames[normalized] = name
+	}
 
 	// Validate each ClaimMapping
 	for i, claim := range c.Claims {
-		// ClaimPath must not be empty
-		if claim.ClaimPath == "" {
-			return fmt.Errorf("claim mapping %d: claimPath is required", i)
+		// Exactly one of ClaimPath and Template
+		if claim.Template != "" {
+			if claim.ClaimPath != "" {
+				errs = append(errs, fmt.Errorf("claim mapping %d: claimPath and template are mutually exclusive", i))
+			}
+			if claim.PathSyntax != "" || claim.Scopes || claim.Required || len(claim.FallbackPaths) > 0 || claim.Default != "" || claim.NumberFormat != "" {
+				errs = append(errs, fmt.Errorf("claim mapping %d: pathSyntax, scopes, required, fallbackPaths, default and numberFormat cannot be used with template", i))
+			}
+			if _, err := compileTemplate(claim.Template); err != nil {
+				errs = append(errs, fmt.Errorf("claim mapping %d: %w", i, err))
+			}
+		} else {
+			if claim.ClaimPath == "" {
+				errs = append(errs, fmt.Errorf("claim mapping %d: claimPath is required", i))
+			} else if _, err := parsePathSyntax(claim.ClaimPath, claim.PathSyntax); err != nil {
+				errs = append(errs, fmt.Errorf("claim mapping %d: %w", i, err))
+			}
+			for _, path := range claim.FallbackPaths {
+				if _, err := parsePathSyntax(path, claim.PathSyntax); err != nil {
+					errs = append(errs, fmt.Errorf("claim mapping %d: fallbackPaths: %w", i, err))
+				}
+			}
+			if claim.Required && claim.Default != "" {
+				errs = append(errs, fmt.Errorf("claim mapping %d: required and default are mutually exclusive", i))
+			}
 		}
 
 		// HeaderName must not be empty
 		if claim.HeaderName == "" {
-			return fmt.Errorf("claim mapping %d: headerName is required", i)
+			errs = append(errs, fmt.Errorf("claim mapping %d: headerName is required", i))
+		} else {
+			checkHeaderName(fmt.Sprintf("claim mapping %d", i), claim.HeaderName)
+		}
+
+		// ArrayFormat must be empty or a known format
+		if claim.ArrayFormat != "" && !arrayFormats[claim.ArrayFormat] {
+			errs = append(errs, fmt.Errorf("claim mapping %d: invalid arrayFormat '%s', must be 'comma', 'space', 'semicolon', 'separator', 'json', 'sf-list' or 'multi'", i, claim.ArrayFormat))
+		}
+
+		// ArraySeparator is required by, and only valid with, the "separator" format
+		if claim.ArrayFormat == "separator" {
+			if claim.ArraySeparator == "" {
+				errs = append(errs, fmt.Errorf("claim mapping %d: arrayFormat 'separator' requires arraySeparator", i))
+			}
+			if strings.IndexFunc(claim.ArraySeparator, unicode.IsControl) >= 0 {
+				errs = append(errs, fmt.Errorf("claim mapping %d: arraySeparator cannot contain control characters", i))
+			}
+		} else if claim.ArraySeparator != "" {
+			errs = append(errs, fmt.Errorf("claim mapping %d: arraySeparator requires arrayFormat 'separator'", i))
 		}
 
-		// ArrayFormat must be "", "comma", or "json"
-		if claim.ArrayFormat != "" && claim.ArrayFormat != "comma" && claim.ArrayFormat != "json" {
-			return fmt.Errorf("claim mapping %d: invalid arrayFormat '%s', must be 'comma' or 'json'", i, claim.ArrayFormat)
+		// NumberFormat must be empty or a known format; Decimals applies to "fixed"
+		if claim.NumberFormat != "" && !numberFormats[claim.NumberFormat] {
+			errs = append(errs, fmt.Errorf("claim mapping %d: invalid numberFormat '%s', must be 'integer', 'fixed' or 'rfc3339'", i, claim.NumberFormat))
+		}
+		if claim.Decimals < 0 || claim.Decimals > maxNumberDecimals {
+			errs = append(errs, fmt.Errorf("claim mapping %d: decimals must be between 0 and %d", i, maxNumberDecimals))
+		}
+		if claim.Decimals != 0 && claim.NumberFormat != "fixed" {
+			errs = append(errs, fmt.Errorf("claim mapping %d: decimals requires numberFormat 'fixed'", i))
 		}
 
-		// Check for duplicate header names (case-insensitive)
-		lowerHeaderName := strings.ToLower(claim.HeaderName)
-		if headerNames[lowerHeaderName] {
-			return fmt.Errorf("duplicate headerName: %s", claim.HeaderName)
+		// Encoding must be empty or a known encoding
+		if claim.Encoding != "" && !headerEncodings[claim.Encoding] {
+			errs = append(errs, fmt.Errorf("claim mapping %d: invalid encoding '%s', must be 'raw', 'percent', 'base64', 'rfc8187' or 'ascii-fold'", i, claim.Encoding))
+		}
+
+		// Transforms must be known and compile
+		if _, err := compileTransforms(claim.Transforms); err != nil {
+			errs = append(errs, prefixErrors(fmt.Sprintf("claim mapping %d", i), err)...)
+		}
+	}
+
+	// Validate the full payload header
+	if ph := c.PayloadHeader; ph.HeaderName != "" {
+		checkHeaderName("payloadHeader", ph.HeaderName)
+		if ph.Format != "" && !payloadHeaderFormats[ph.Format] {
+			errs = append(errs, fmt.Errorf("payloadHeader: invalid format '%s', must be 'base64' or 'json'", ph.Format))
+		}
+		for _, name := range ph.Claims {
+			if name == "" {
+				errs = append(errs, fmt.Errorf("payloadHeader: claims cannot contain an empty value"))
+			}
+		}
+	} else if ph.Format != "" || len(ph.Claims) > 0 || ph.IncludeHeader || ph.Override {
+		errs = append(errs, fmt.Errorf("payloadHeader: headerName is required"))
+	}
+
+	// Stripping must never remove the header the token is read from
+	if c.StripHeaderPrefix != "" {
+		if !c.StripIncomingHeaders {
+			errs = append(errs, fmt.Errorf("stripHeaderPrefix requires stripIncomingHeaders"))
+		} else if strings.HasPrefix(normalizeHeaderName(c.SourceHeader), normalizeHeaderName(c.StripHeaderPrefix)) {
+			errs = append(errs, fmt.Errorf("stripHeaderPrefix '%s' would strip sourceHeader '%s'", c.StripHeaderPrefix, c.SourceHeader))
+		}
+	}
+	if c.StripIncomingHeaders {
+		for i, claim := range c.Claims {
+			if normalizeHeaderName(claim.HeaderName) == normalizeHeaderName(c.SourceHeader) {
+				errs = append(errs, fmt.Errorf("claim mapping %d: headerName '%s' would strip sourceHeader with stripIncomingHeaders", i, claim.HeaderName))
+			}
+		}
+		if c.PayloadHeader.HeaderName != "" && normalizeHeaderName(c.PayloadHeader.HeaderName) == normalizeHeaderName(c.SourceHeader) {
+			errs = append(errs, fmt.Errorf("payloadHeader: headerName '%s' would strip sourceHeader with stripIncomingHeaders", c.PayloadHeader.HeaderName))
 		}
-		headerNames[lowerHeaderName] = true
 	}
 
 	// Validate Sections array
 	if len(c.Sections) == 0 {
-		return fmt.Errorf("sections array cannot be empty")
+		errs = append(errs, fmt.Errorf("sections array cannot be empty"))
 	}
 
 	for _, section := range c.Sections {
 		if section != "header" && section != "payload" {
-			return fmt.Errorf("invalid section '%s', must be 'header' or 'payload'", section)
+			errs = append(errs, fmt.Errorf("invalid section '%s', must be 'header' or 'payload'", section))
 		}
 	}
 
 	// Check MaxClaimDepth > 0
 	if c.MaxClaimDepth <= 0 {
-		return fmt.Errorf("maxClaimDepth must be greater than 0")
+		errs = append(errs, fmt.Errorf("maxClaimDepth must be greater than 0"))
 	}
 
 	// Check MaxHeaderSize > 0
 	if c.MaxHeaderSize <= 0 {
-		return fmt.Errorf("maxHeaderSize must be greater than 0")
+		errs = append(errs, fmt.Errorf("maxHeaderSize must be greater than 0"))
 	}
 
 	// Validate LogLevel if provided
@@ -177,9 +665,122 @@ func (c *Config) Validate() error {
 			"error": true,
 		}
 		if !validLevels[c.LogLevel] {
-			return fmt.Errorf("invalid logLevel '%s', must be 'debug', 'info', 'warn', or 'error'", c.LogLevel)
+			errs = append(errs, fmt.Errorf("invalid logLevel '%s', must be 'debug', 'info', 'warn', or 'error'", c.LogLevel))
 		}
 	}
 
-	return nil
+	// Validate time claim durations
+	if c.ClockSkew != "" {
+		if d, err := time.ParseDuration(c.ClockSkew); err != nil || d < 0 {
+			errs = append(errs, fmt.Errorf("invalid clockSkew '%s', must be a non-negative duration", c.ClockSkew))
+		}
+	}
+	if c.MaxTokenAge != "" {
+		if d, err := time.ParseDuration(c.MaxTokenAge); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("invalid maxTokenAge '%s', must be a positive duration", c.MaxTokenAge))
+		}
+	}
+
+	// Validate required claim paths
+	for i, path := range c.RequiredClaims {
+		if path == "" {
+			errs = append(errs, fmt.Errorf("requiredClaims %d: claim path cannot be empty", i))
+		} else if _, err := parseClaimPath(path); err != nil {
+			errs = append(errs, fmt.Errorf("requiredClaims %d: %w", i, err))
+		}
+	}
+
+	// Validate rules (compiles regexes and numeric operands)
+	if _, err := compileRules(c.Rules); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate policies
+	if _, err := compilePolicies(c.Policies); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Validate scope settings; scopes are space-delimited so cannot contain spaces
+	for _, list := range [][]string{c.ScopeClaims, c.RequiredScopes, c.RequiredAnyScopes} {
+		for _, value := range list {
+			if value == "" || strings.ContainsAny(value, " \t") {
+				errs = append(errs, fmt.Errorf("invalid scope setting '%s': must be non-empty and contain no whitespace", value))
+			}
+		}
+	}
+	for i, path := range c.ScopeClaims {
+		if _, err := parseClaimPath(path); err != nil {
+			errs = append(errs, fmt.Errorf("scopeClaims %d: %w", i, err))
+		}
+	}
+
+	// Validate issuer and audience allow-lists
+	for _, iss := range c.AllowedIssuers {
+		if iss == "" {
+			errs = append(errs, fmt.Errorf("allowedIssuers cannot contain an empty value"))
+		}
+	}
+	for _, aud := range c.AllowedAudiences {
+		if aud == "" {
+			errs = append(errs, fmt.Errorf("allowedAudiences cannot contain an empty value"))
+		}
+	}
+
+	// Validate algorithm allow-list
+	for _, name := range c.AllowedAlgorithms {
+		if strings.EqualFold(name, "none") {
+			if c.Verification.Enabled {
+				errs = append(errs, fmt.Errorf("allowedAlgorithms: 'none' cannot be allowed when verification is enabled"))
+			}
+			continue
+		}
+		if _, ok := algorithms[name]; !ok {
+			errs = append(errs, fmt.Errorf("allowedAlgorithms: unsupported algorithm '%s'", name))
+		}
+	}
+
+	// Validate signature verification keys
+	if c.Verification.Enabled {
+		v := c.Verification
+		if len(v.Keys) == 0 && v.JWKSFile == "" && v.JWKSURL == "" {
+			errs = append(errs, fmt.Errorf("verification: at least one key, jwksFile or jwksURL is required when enabled"))
+		}
+		if v.JWKSURL != "" {
+			u, err := url.Parse(v.JWKSURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("verification: invalid jwksURL '%s', must be an http or https URL", v.JWKSURL))
+			}
+		}
+		durations := []struct{ name, value string }{
+			{"jwksCacheTTL", v.JWKSCacheTTL},
+			{"jwksFetchTimeout", v.JWKSFetchTimeout},
+			{"jwksMinRefetchInterval", v.JWKSMinRefetchInterval},
+		}
+		for _, d := range durations {
+			if d.value == "" {
+				continue
+			}
+			if parsed, err := time.ParseDuration(d.value); err != nil || parsed <= 0 {
+				errs = append(errs, fmt.Errorf("verification: invalid %s '%s', must be a positive duration", d.name, d.value))
+			}
+		}
+		if _, err := LoadKeySet(c.Verification); err != nil {
+			errs = append(errs, fmt.Errorf("verification: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// prefixErrors prefixes every error joined in err (see errors.Join) with
+// prefix, so each line of the combined message names the offending item.
+func prefixErrors(prefix string, err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range joined.Unwrap() {
+			errs = append(errs, prefixErrors(prefix, e)...)
+		}
+		return errs
+	}
+	return []error{fmt.Errorf("%s: %w", prefix, err)}
 }
@@ -1,6 +1,7 @@
 package traefik_jwt_decoder_plugin
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -533,3 +534,797 @@ func TestValidate_LogMissingClaims(t *testing.T) {
 		})
 	}
 }
+
+// weakRSAKeyPEM is a 1024-bit RSA public key, below the enforced minimum
+const weakRSAKeyPEM = `-----BEGIN PUBLIC KEY-----
+MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC+ATnR3yqkqgyoH/1rqi3lXbSf
+KqJcPwh2B0OToW7R7ECrqQIHO4YKSGm7cBWcFkveCk+diIhYCdoIHmv5Z8dRM4Dz
+rOupvbleg48fTsJQt7giz3YRd7LcsldHxks/rvZe0F3FgEZ1guyGa3uKXGR/J16d
+wjAqefvUp1T/ye9HAQIDAQAB
+-----END PUBLIC KEY-----`
+
+// TestValidate_Verification verifies signature verification settings are validated
+func TestValidate_Verification(t *testing.T) {
+	tests := []struct {
+		name         string
+		verification VerificationConfig
+		wantErr      bool
+	}{
+		{
+			name:         "verification disabled",
+			verification: VerificationConfig{},
+			wantErr:      false,
+		},
+		{
+			name:         "enabled with inline secret",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "shared-secret"}}},
+			wantErr:      false,
+		},
+		{
+			name:         "enabled without keys",
+			verification: VerificationConfig{Enabled: true},
+			wantErr:      true,
+		},
+		{
+			name:         "enabled with 1024-bit RSA key",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{PublicKey: weakRSAKeyPEM}}},
+			wantErr:      true,
+		},
+		{
+			name:         "enabled with unreadable jwksFile",
+			verification: VerificationConfig{Enabled: true, JWKSFile: "/nonexistent/jwks.json"},
+			wantErr:      true,
+		},
+		{
+			name:         "enabled with jwksURL",
+			verification: VerificationConfig{Enabled: true, JWKSURL: "https://idp.example.com/.well-known/jwks.json", JWKSCacheTTL: "10m"},
+			wantErr:      false,
+		},
+		{
+			name:         "jwksURL with unsupported scheme",
+			verification: VerificationConfig{Enabled: true, JWKSURL: "file:///etc/jwks.json"},
+			wantErr:      true,
+		},
+		{
+			name:         "invalid jwksCacheTTL",
+			verification: VerificationConfig{Enabled: true, JWKSURL: "https://idp.example.com/jwks", JWKSCacheTTL: "soon"},
+			wantErr:      true,
+		},
+		{
+			name:         "non-positive jwksFetchTimeout",
+			verification: VerificationConfig{Enabled: true, JWKSURL: "https://idp.example.com/jwks", JWKSFetchTimeout: "0s"},
+			wantErr:      true,
+		},
+		{
+			name:         "enabled with unreadable secretFile",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{SecretFile: "/nonexistent/secret"}}},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:      []string{"payload"},
+				MaxClaimDepth: 10,
+				MaxHeaderSize: 8192,
+				Verification:  tt.verification,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_AllowedAlgorithms verifies the algorithm allow-list and key bindings are validated
+func TestValidate_AllowedAlgorithms(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowed      []string
+		verification VerificationConfig
+		wantErr      bool
+	}{
+		{
+			name:    "supported algorithms",
+			allowed: []string{"RS256", "ES256", "EdDSA"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown algorithm",
+			allowed: []string{"RS257"},
+			wantErr: true,
+		},
+		{
+			name:    "none without verification",
+			allowed: []string{"none"},
+			wantErr: false,
+		},
+		{
+			name:         "none with verification",
+			allowed:      []string{"none"},
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "shared-secret"}}},
+			wantErr:      true,
+		},
+		{
+			name:         "secret bound to HMAC algorithm",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "shared-secret", Algorithm: "HS384"}}},
+			wantErr:      false,
+		},
+		{
+			name:         "secret bound to RSA algorithm",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "shared-secret", Algorithm: "RS256"}}},
+			wantErr:      true,
+		},
+		{
+			name:         "key bound to unknown algorithm",
+			verification: VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "shared-secret", Algorithm: "HS1"}}},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:          []string{"payload"},
+				MaxClaimDepth:     10,
+				MaxHeaderSize:     8192,
+				AllowedAlgorithms: tt.allowed,
+				Verification:      tt.verification,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_TimeClaims verifies clockSkew and maxTokenAge durations are validated
+func TestValidate_TimeClaims(t *testing.T) {
+	tests := []struct {
+		name        string
+		clockSkew   string
+		maxTokenAge string
+		wantErr     bool
+	}{
+		{"defaults", "", "", false},
+		{"valid durations", "30s", "24h", false},
+		{"zero skew", "0s", "", false},
+		{"negative skew", "-5s", "", true},
+		{"unparseable skew", "thirty", "", true},
+		{"zero maxTokenAge", "", "0s", true},
+		{"unparseable maxTokenAge", "", "1 day", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:           []string{"payload"},
+				MaxClaimDepth:      10,
+				MaxHeaderSize:      8192,
+				ValidateTimeClaims: true,
+				ClockSkew:          tt.clockSkew,
+				MaxTokenAge:        tt.maxTokenAge,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_IssuerAudience verifies allowedIssuers and allowedAudiences are validated
+func TestValidate_IssuerAudience(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuers   []string
+		audiences []string
+		wantErr   bool
+	}{
+		{"unset", nil, nil, false},
+		{"valid values", []string{"https://idp.example.com/"}, []string{"api-a", "api-b"}, false},
+		{"empty issuer", []string{""}, nil, true},
+		{"empty audience", nil, []string{"api-a", ""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:         []string{"payload"},
+				MaxClaimDepth:    10,
+				MaxHeaderSize:    8192,
+				AllowedIssuers:   tt.issuers,
+				AllowedAudiences: tt.audiences,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_RequiredClaims verifies requiredClaims entries are validated
+func TestValidate_RequiredClaims(t *testing.T) {
+	tests := []struct {
+		name           string
+		requiredClaims []string
+		wantErr        bool
+	}{
+		{"unset", nil, false},
+		{"valid paths", []string{"sub", "custom.tenant_id"}, false},
+		{"empty path", []string{"sub", ""}, true},
+		{"array selectors", []string{"groups[0]", "orgs[*].id"}, false},
+		{"malformed selector", []string{"groups[first]"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id", Required: true},
+				},
+				Sections:       []string{"payload"},
+				MaxClaimDepth:  10,
+				MaxHeaderSize:  8192,
+				RequiredClaims: tt.requiredClaims,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Rules verifies rules are validated at startup
+func TestValidate_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []Rule
+		wantErr bool
+	}{
+		{"no rules", nil, false},
+		{"valid rules", []Rule{
+			{ClaimPath: "roles", Operator: "contains", Value: "admin"},
+			{ClaimPath: "email", Operator: "regex", Value: `@example\.com$`},
+			{ClaimPath: "level", Operator: "gte", Value: "3"},
+		}, false},
+		{"invalid regex", []Rule{{ClaimPath: "email", Operator: "regex", Value: "("}}, true},
+		{"unknown operator", []Rule{{ClaimPath: "email", Operator: "matches", Value: "x"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:      []string{"payload"},
+				MaxClaimDepth: 10,
+				MaxHeaderSize: 8192,
+				Rules:         tt.rules,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Policies verifies policies are validated at startup
+func TestValidate_Policies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []Policy
+		wantErr  bool
+	}{
+		{"no policies", nil, false},
+		{"valid policy", []Policy{{
+			Methods: []string{"DELETE"},
+			Path:    "/admin/*",
+			Rules:   []Rule{{ClaimPath: "roles", Operator: "contains", Value: "admin"}},
+		}}, false},
+		{"policy without rules", []Policy{{PathPrefix: "/admin"}}, true},
+		{"policy with invalid regex", []Policy{{
+			PathPrefix: "/admin",
+			Rules:      []Rule{{ClaimPath: "email", Operator: "regex", Value: "["}},
+		}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{
+				Claims: []ClaimMapping{
+					{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				},
+				Sections:      []string{"payload"},
+				MaxClaimDepth: 10,
+				MaxHeaderSize: 8192,
+				Policies:      tt.policies,
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Scopes verifies scope settings are validated at startup
+func TestValidate_Scopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(c *Config)
+		wantErr bool
+	}{
+		{"unset", func(c *Config) {}, false},
+		{"valid", func(c *Config) {
+			c.ScopeClaims = []string{"scp"}
+			c.RequiredScopes = []string{"reports:read"}
+			c.RequiredAnyScopes = []string{"admin", "billing"}
+		}, false},
+		{"empty required scope", func(c *Config) { c.RequiredScopes = []string{""} }, true},
+		{"whitespace in scope", func(c *Config) { c.RequiredAnyScopes = []string{"read write"} }, true},
+		{"empty scope claim", func(c *Config) { c.ScopeClaims = []string{""} }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			tt.mutate(config)
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_ClaimPathSyntax verifies malformed claim mapping paths are rejected
+func TestValidate_ClaimPathSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"dot notation", "user.profile.email", false},
+		{"index", "groups[0]", false},
+		{"wildcard", "orgs[*].id", false},
+		{"slice", "addresses[0:2].city", false},
+		{"unterminated selector", "groups[0", true},
+		{"double dot", "user..email", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: tt.path, HeaderName: "X-Claim"}}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_PathSyntax verifies pathSyntax values and pointer paths are validated
+func TestValidate_PathSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		syntax  string
+		wantErr bool
+	}{
+		{"default dot", "user.email", "", false},
+		{"explicit dot", `["https://example.com/roles"]`, "dot", false},
+		{"pointer", "/https:~1~1example.com~1roles", "pointer", false},
+		{"pointer without slash", "https:~1~1example.com", "pointer", true},
+		{"pointer invalid escape", "/a~2", "pointer", true},
+		{"unknown syntax", "user.email", "jsonpath", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: tt.path, PathSyntax: tt.syntax, HeaderName: "X-Claim"}}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Transforms verifies claim mapping transforms are validated at startup
+func TestValidate_Transforms(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []Transform
+		wantErr    bool
+	}{
+		{"none", nil, false},
+		{"valid pipeline", []Transform{
+			{Type: "stripPrefix", Value: "auth0|"},
+			{Type: "lower"},
+			{Type: "regex", Value: "^(.*)$"},
+			{Type: "lookup", Table: map[string]string{"a": "b"}},
+		}, false},
+		{"unknown type", []Transform{{Type: "reverse"}}, true},
+		{"invalid regex", []Transform{{Type: "lower"}, {Type: "regex", Value: "("}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id", Transforms: tt.transforms}}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Template verifies template mappings are validated at startup
+func TestValidate_Template(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping ClaimMapping
+		wantErr bool
+	}{
+		{"template only", ClaimMapping{Template: "tenant={{.payload.tenant}}", HeaderName: "X-Identity"}, false},
+		{"template with transforms", ClaimMapping{Template: "{{.payload.sub}}", HeaderName: "X-Identity", Transforms: []Transform{{Type: "lower"}}}, false},
+		{"neither claimPath nor template", ClaimMapping{HeaderName: "X-Identity"}, true},
+		{"both claimPath and template", ClaimMapping{ClaimPath: "sub", Template: "{{.payload.sub}}", HeaderName: "X-Identity"}, true},
+		{"template with required", ClaimMapping{Template: "{{.payload.sub}}", HeaderName: "X-Identity", Required: true}, true},
+		{"unparseable template", ClaimMapping{Template: "{{.payload.sub", HeaderName: "X-Identity"}, true},
+		{"unknown function", ClaimMapping{Template: "{{upper .payload.sub}}", HeaderName: "X-Identity"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{tt.mapping}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_FallbackPathsAndDefault verifies fallback and default settings
+func TestValidate_FallbackPathsAndDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping ClaimMapping
+		wantErr bool
+	}{
+		{"fallbacks and default", ClaimMapping{ClaimPath: "email", FallbackPaths: []string{"preferred_username", "upn"}, Default: "anonymous", HeaderName: "X-User"}, false},
+		{"pointer fallbacks", ClaimMapping{ClaimPath: "/email", PathSyntax: "pointer", FallbackPaths: []string{"/upn"}, HeaderName: "X-User"}, false},
+		{"malformed fallback", ClaimMapping{ClaimPath: "email", FallbackPaths: []string{"upn["}, HeaderName: "X-User"}, true},
+		{"fallback not a pointer", ClaimMapping{ClaimPath: "/email", PathSyntax: "pointer", FallbackPaths: []string{"upn"}, HeaderName: "X-User"}, true},
+		{"required with default", ClaimMapping{ClaimPath: "email", Default: "anonymous", Required: true, HeaderName: "X-User"}, true},
+		{"template with default", ClaimMapping{Template: "{{.payload.email}}", Default: "anonymous", HeaderName: "X-User"}, true},
+		{"template with fallbacks", ClaimMapping{Template: "{{.payload.email}}", FallbackPaths: []string{"upn"}, HeaderName: "X-User"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{tt.mapping}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_ArrayFormats verifies array formats and custom separators
+func TestValidate_ArrayFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		separator string
+		wantErr   bool
+	}{
+		{"space", "space", "", false},
+		{"semicolon", "semicolon", "", false},
+		{"multi", "multi", "", false},
+		{"sf-list", "sf-list", "", false},
+		{"custom separator", "separator", " | ", false},
+		{"separator without arraySeparator", "separator", "", true},
+		{"separator with control character", "separator", "\r\n", true},
+		{"arraySeparator without separator format", "comma", " | ", true},
+		{"unknown format", "pipe", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "roles", HeaderName: "X-Roles", ArrayFormat: tt.format, ArraySeparator: tt.separator}}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_NumberFormat verifies numberFormat and decimals validation
+func TestValidate_NumberFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		decimals int
+		template string
+		wantErr  bool
+	}{
+		{"integer", "integer", 0, "", false},
+		{"fixed", "fixed", 2, "", false},
+		{"fixed maximum decimals", "fixed", 18, "", false},
+		{"rfc3339", "rfc3339", 0, "", false},
+		{"unknown format", "hex", 0, "", true},
+		{"negative decimals", "fixed", -1, "", true},
+		{"too many decimals", "fixed", 19, "", true},
+		{"decimals without fixed", "integer", 2, "", true},
+		{"numberFormat with template", "integer", 0, "{{ .payload.sub }}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			mapping := ClaimMapping{ClaimPath: "exp", HeaderName: "X-Expires", NumberFormat: tt.format, Decimals: tt.decimals}
+			if tt.template != "" {
+				mapping.ClaimPath = ""
+				mapping.Template = tt.template
+			}
+			config.Claims = []ClaimMapping{mapping}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_Encoding verifies encoding validation
+func TestValidate_Encoding(t *testing.T) {
+	for _, encoding := range []string{"", "raw", "percent", "base64", "rfc8187", "ascii-fold"} {
+		config := CreateConfig()
+		config.Claims = []ClaimMapping{{ClaimPath: "name", HeaderName: "X-User-Name", Encoding: encoding}}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Validate() failed for encoding %q: %v", encoding, err)
+		}
+	}
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{{ClaimPath: "name", HeaderName: "X-User-Name", Encoding: "latin1"}}
+	if err := config.Validate(); err == nil {
+		t.Error("Validate() expected error for unknown encoding, got nil")
+	}
+}
+
+// TestValidate_PayloadHeader verifies payloadHeader validation
+func TestValidate_PayloadHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  []ClaimMapping
+		payload PayloadHeaderConfig
+		strip   bool
+		wantErr bool
+	}{
+		{"payload header without claims", nil, PayloadHeaderConfig{HeaderName: "X-Jwt-Payload"}, false, false},
+		{"all options", nil, PayloadHeaderConfig{HeaderName: "X-Jwt-Payload", Format: "json", Claims: []string{"sub"}, IncludeHeader: true, Override: true}, false, false},
+		{"options without headerName", []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}, PayloadHeaderConfig{Format: "json"}, false, true},
+		{"unknown format", nil, PayloadHeaderConfig{HeaderName: "X-Jwt-Payload", Format: "yaml"}, false, true},
+		{"empty claim name", nil, PayloadHeaderConfig{HeaderName: "X-Jwt-Payload", Claims: []string{""}}, false, true},
+		{"invalid headerName", nil, PayloadHeaderConfig{HeaderName: "X Jwt"}, false, true},
+		{"protected headerName", nil, PayloadHeaderConfig{HeaderName: "Authorization"}, false, true},
+		{"collides with claim header", []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-Jwt-Payload"}}, PayloadHeaderConfig{HeaderName: "x_jwt_payload"}, false, true},
+		{"strips sourceHeader", nil, PayloadHeaderConfig{HeaderName: "X-Token"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = tt.claims
+			config.PayloadHeader = tt.payload
+			config.StripIncomingHeaders = tt.strip
+			if tt.strip {
+				config.SourceHeader = "X-Token"
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_StripIncomingHeaders verifies stripping cannot remove the source header
+func TestValidate_StripIncomingHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		strip      bool
+		prefix     string
+		headerName string
+		wantErr    bool
+	}{
+		{"strip mapped headers", true, "", "X-User-Id", false},
+		{"strip with prefix", true, "X-Jwt-", "X-User-Id", false},
+		{"prefix without strip", false, "X-Jwt-", "X-User-Id", true},
+		{"prefix matches source header", true, "auth", "X-User-Id", true},
+		{"headerName is source header", true, "", "authorization", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.StripIncomingHeaders = tt.strip
+			config.StripHeaderPrefix = tt.prefix
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: tt.headerName}}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_ProtectedHeaders verifies protected header and allow-list checks at startup
+func TestValidate_ProtectedHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		headerName string
+		protected  []string
+		replace    bool
+		patterns   []string
+		wantErr    bool
+	}{
+		{"safe header", "X-User-Id", nil, false, nil, false},
+		{"built-in protected header", "Authorization", nil, false, nil, true},
+		{"built-in protected header, any case", "x-real-ip", nil, false, nil, true},
+		{"extended protected header", "X-Internal-Auth", []string{"X-Internal-Auth"}, false, nil, true},
+		{"replaced list allows built-in", "X-Forwarded-Prefix", []string{"X-Internal-Auth"}, true, nil, false},
+		{"replaced list still protects its entries", "X-Internal-Auth", []string{"X-Internal-Auth"}, true, nil, true},
+		{"replace without list", "X-User-Id", nil, true, nil, true},
+		{"empty protected header", "X-User-Id", []string{" "}, false, nil, true},
+		{"matches allow-list", "X-Auth-User", nil, false, []string{"X-Auth-*"}, false},
+		{"outside allow-list", "X-User-Id", nil, false, []string{"X-Auth-*"}, true},
+		{"invalid pattern", "X-Auth-User", nil, false, []string{"X-Auth-["}, true},
+		{"empty pattern", "X-Auth-User", nil, false, []string{""}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: tt.headerName}}
+			config.ProtectedHeaders = tt.protected
+			config.ReplaceProtectedHeaders = tt.replace
+			config.AllowedHeaderPatterns = tt.patterns
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_HeaderNameSyntax verifies headerName must be an RFC 7230 token
+// and may not collide with another mapping after normalization
+func TestValidate_HeaderNameSyntax(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		wantErr bool
+	}{
+		{"token characters", []string{"X-User_Id.v2", "X-Tenant!"}, false},
+		{"space", []string{"X User"}, true},
+		{"colon", []string{"X-User:"}, true},
+		{"non-ASCII", []string{"X-Usér"}, true},
+		{"separator", []string{"X-(User)"}, true},
+		{"case-insensitive duplicate", []string{"X-User", "x-user"}, true},
+		{"underscore collides with hyphen", []string{"x_user", "X-User"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			for _, header := range tt.headers {
+				config.Claims = append(config.Claims, ClaimMapping{ClaimPath: "sub", HeaderName: header})
+			}
+
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidate_AggregatesErrors verifies every problem is reported at once
+func TestValidate_AggregatesErrors(t *testing.T) {
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "sub", HeaderName: "X User"},
+		{ClaimPath: "email", HeaderName: "X-Email", ArrayFormat: "pipe", Transforms: []Transform{
+			{Type: "stripPrefix"},
+			{Type: "truncate"},
+		}},
+		{ClaimPath: "role", HeaderName: "Cookie:"},
+	}
+	config.AllowedHeaderPatterns = []string{"X-*"}
+	config.Sections = []string{"body"}
+	config.MaxHeaderSize = 0
+	config.Rules = []Rule{
+		{ClaimPath: "roles", Operator: "bogus"},
+		{ClaimPath: "level", Operator: "gt", Value: "high"},
+	}
+	config.Policies = []Policy{
+		{Path: "/a", PathPrefix: "/b", Methods: []string{""}},
+		{Path: "[", Rules: []Rule{{ClaimPath: "sub", Operator: "exists"}}},
+	}
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() expected error, got nil")
+	}
+	for _, want := range []string{
+		"claim mapping 0: invalid headerName 'X User'",
+		"claim mapping 1: invalid arrayFormat 'pipe'",
+		"claim mapping 1: transform 0: transform 'stripPrefix' requires a value",
+		"claim mapping 1: transform 1: transform 'truncate' requires a length",
+		"claim mapping 2: invalid headerName 'Cookie:'",
+		"claim mapping 2: headerName 'Cookie:' does not match",
+		"invalid section 'body'",
+		"maxHeaderSize must be greater than 0",
+		"rule 0: invalid operator 'bogus'",
+		"rule 1: operator 'gt' requires a numeric value",
+		"policy 0: path and pathPrefix are mutually exclusive",
+		"policy 0: methods cannot contain an empty value",
+		"policy 0: at least one rule is required",
+		"policy 1: invalid path pattern '['",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %q, want it to contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// headerEncodings lists the supported ClaimMapping.Encoding values.
+var headerEncodings = map[string]bool{
+	"raw":        true,
+	"percent":    true,
+	"base64":     true,
+	"rfc8187":    true,
+	"ascii-fold": true,
+}
+
+// rfc8187AttrChars are the attr-char bytes RFC 8187 leaves unescaped;
+// letters and digits are handled separately.
+const rfc8187AttrChars = "!#$&+-.^_`|~"
+
+// asciiFolds maps accented Latin letters and typographic punctuation to their
+// closest ASCII spelling for the "ascii-fold" encoding.
+var asciiFolds = buildASCIIFolds(map[string]string{
+	"A":   "ÀÁÂÃÄÅĀĂĄ",
+	"a":   "àáâãäåāăą",
+	"AE":  "Æ",
+	"ae":  "æ",
+	"C":   "ÇĆĈĊČ",
+	"c":   "çćĉċč",
+	"D":   "ĎĐÐ",
+	"d":   "ďđð",
+	"E":   "ÈÉÊËĒĔĖĘĚ",
+	"e":   "èéêëēĕėęě",
+	"G":   "ĜĞĠĢ",
+	"g":   "ĝğġģ",
+	"H":   "ĤĦ",
+	"h":   "ĥħ",
+	"I":   "ÌÍÎÏĨĪĬĮİ",
+	"i":   "ìíîïĩīĭįı",
+	"J":   "Ĵ",
+	"j":   "ĵ",
+	"K":   "Ķ",
+	"k":   "ķ",
+	"L":   "ĹĻĽĿŁ",
+	"l":   "ĺļľŀł",
+	"N":   "ÑŃŅŇ",
+	"n":   "ñńņň",
+	"O":   "ÒÓÔÕÖØŌŎŐ",
+	"o":   "òóôõöøōŏő",
+	"OE":  "Œ",
+	"oe":  "œ",
+	"R":   "ŔŖŘ",
+	"r":   "ŕŗř",
+	"S":   "ŚŜŞŠ",
+	"s":   "śŝşš",
+	"ss":  "ß",
+	"T":   "ŢŤŦ",
+	"t":   "ţťŧ",
+	"TH":  "Þ",
+	"th":  "þ",
+	"U":   "ÙÚÛÜŨŪŬŮŰŲ",
+	"u":   "ùúûüũūŭůűų",
+	"W":   "Ŵ",
+	"w":   "ŵ",
+	"Y":   "ÝŶŸ",
+	"y":   "ýÿŷ",
+	"Z":   "ŹŻŽ",
+	"z":   "źżž",
+	"'":   "‘’‚′",
+	"\"":  "“”„″«»",
+	"-":   "‐‑‒–—―",
+	"...": "…",
+	" ":   "\u00a0\u2007\u2009\u202f",
+})
+
+// buildASCIIFolds inverts a replacement → characters table into a rune lookup.
+func buildASCIIFolds(groups map[string]string) map[rune]string {
+	folds := make(map[rune]string)
+	for replacement, chars := range groups {
+		for _, r := range chars {
+			folds[r] = replacement
+		}
+	}
+	return folds
+}
+
+// EncodeHeaderValue encodes a header value so that non-ASCII text survives
+// backends that do not decode header bytes as UTF-8. It is applied after
+// transforms and before SanitizeHeaderValue.
+//
+// Encodings:
+//   - "" or "raw": the value unchanged (UTF-8 bytes are sent as-is)
+//   - "percent": non-ASCII bytes, control characters and '%' percent-encoded,
+//     e.g. "José Müller" → "Jos%C3%A9 M%C3%BCller"
+//   - "base64": standard base64 of the UTF-8 bytes
+//   - "rfc8187": an RFC 8187 ext-value, e.g. "José" → "UTF-8''Jos%C3%A9"
+//   - "ascii-fold": accents removed and typographic punctuation replaced,
+//     e.g. "José Müller" → "Jose Muller"; other non-ASCII characters become '?'
+//
+// Example:
+//   encoded, _ := EncodeHeaderValue("José Müller", "rfc8187")
+//   // Returns: "UTF-8''Jos%C3%A9%20M%C3%BCller"
+//
+// Returns an error if encoding is unknown.
+func EncodeHeaderValue(value, encoding string) (string, error) {
+	switch encoding {
+	case "", "raw":
+		return value, nil
+	case "percent":
+		return percentEncode(value, func(c byte) bool {
+			return c >= 0x20 && c < 0x7F && c != '%'
+		}), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	case "rfc8187":
+		return "UTF-8''" + percentEncode(value, func(c byte) bool {
+			return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+				strings.IndexByte(rfc8187AttrChars, c) >= 0
+		}), nil
+	case "ascii-fold":
+		return asciiFold(value), nil
+	default:
+		return "", fmt.Errorf("unsupported encoding '%s'", encoding)
+	}
+}
+
+// encodeHeaderValues applies EncodeHeaderValue to every value.
+func encodeHeaderValues(values []string, encoding string) ([]string, error) {
+	if encoding == "" || encoding == "raw" {
+		return values, nil
+	}
+	encoded := make([]string, len(values))
+	for i, value := range values {
+		e, err := EncodeHeaderValue(value, encoding)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i] = e
+	}
+	return encoded, nil
+}
+
+// percentEncode escapes every byte of value for which keep reports false as
+// %XX with uppercase hex digits.
+func percentEncode(value string, keep func(byte) bool) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	b.Grow(len(value))
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if keep(c) {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0F])
+	}
+	return b.String()
+}
+
+// asciiFold replaces non-ASCII runes with their asciiFolds spelling, or '?'
+// when there is none. Invalid UTF-8 bytes also become '?'; characters
+// SanitizeHeaderValue strips are dropped.
+func asciiFold(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+	for _, r := range value {
+		switch {
+		case isUnsafeHeaderRune(r):
+			// Dropped here, as SanitizeHeaderValue would
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case asciiFolds[r] != "":
+			b.WriteString(asciiFolds[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
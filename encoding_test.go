@@ -0,0 +1,60 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"testing"
+)
+
+// TestEncodeHeaderValue verifies each header value encoding
+func TestEncodeHeaderValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		encoding string
+		want     string
+	}{
+		{"default is raw", "José Müller", "", "José Müller"},
+		{"raw", "José Müller", "raw", "José Müller"},
+		{"percent", "José Müller", "percent", "Jos%C3%A9 M%C3%BCller"},
+		{"percent escapes percent and controls", "100%\r\n", "percent", "100%25%0D%0A"},
+		{"percent keeps ASCII", "user@example.com, admin", "percent", "user@example.com, admin"},
+		{"base64", "José", "base64", "Sm9zw6k="},
+		{"rfc8187", "José Müller", "rfc8187", "UTF-8''Jos%C3%A9%20M%C3%BCller"},
+		{"rfc8187 escapes separators", "a;b=c", "rfc8187", "UTF-8''a%3Bb%3Dc"},
+		{"ascii-fold", "José Müller", "ascii-fold", "Jose Muller"},
+		{"ascii-fold ligatures", "Æsir Straße Œuvre", "ascii-fold", "AEsir Strasse OEuvre"},
+		{"ascii-fold punctuation", "“Zoë’s” – café…", "ascii-fold", "\"Zoe's\" - cafe..."},
+		{"ascii-fold unknown characters", "李 Smith", "ascii-fold", "? Smith"},
+		{"ascii-fold drops bidi and separators", "admin\u202e\u2028user", "ascii-fold", "adminuser"},
+		{"ascii-fold invalid UTF-8", "a\xffb", "ascii-fold", "a?b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeHeaderValue(tt.value, tt.encoding)
+			if err != nil {
+				t.Fatalf("EncodeHeaderValue() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodeHeaderValue(%q, %q) = %q, want %q", tt.value, tt.encoding, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEncodeHeaderValue_Unknown verifies unknown encodings are rejected
+func TestEncodeHeaderValue_Unknown(t *testing.T) {
+	if _, err := EncodeHeaderValue("José", "latin1"); err == nil {
+		t.Error("EncodeHeaderValue() expected error for unknown encoding, got nil")
+	}
+}
+
+// TestEncodeHeaderValues verifies every value is encoded
+func TestEncodeHeaderValues(t *testing.T) {
+	got, err := encodeHeaderValues([]string{"José", "Zoë"}, "percent")
+	if err != nil {
+		t.Fatalf("encodeHeaderValues() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "Jos%C3%A9" || got[1] != "Zo%C3%AB" {
+		t.Errorf("encodeHeaderValues() = %q", got)
+	}
+}
@@ -3,25 +3,38 @@ package traefik_jwt_decoder_plugin
 import (
 	"fmt"
 	"net/http"
+	"path"
 	"strings"
 )
 
-// protectedHeaders is a blacklist of HTTP headers that should never be overridden
-// by JWT claims. These headers are security-critical and could compromise the
-// request if modified by external data.
+// protectedHeaders is the built-in blacklist of HTTP headers that should never be
+// overridden by JWT claims. These headers are security-critical and could
+// compromise the request if modified by external data. Config.ProtectedHeaders
+// extends or replaces it.
 var protectedHeaders = map[string]bool{
-	"host":              true, // Target host
-	"x-forwarded-for":   true, // Client IP (proxy chain)
-	"x-forwarded-host":  true, // Original host
-	"x-forwarded-proto": true, // Original protocol (http/https)
-	"x-forwarded-port":  true, // Original port
-	"x-real-ip":         true, // Real client IP
-	"content-length":    true, // Message body length
-	"content-type":      true, // Media type
-	"transfer-encoding": true, // Transfer encoding method
+	"host":                true, // Target host
+	"x-forwarded-for":     true, // Client IP (proxy chain)
+	"x-forwarded-host":    true, // Original host
+	"x-forwarded-proto":   true, // Original protocol (http/https)
+	"x-forwarded-port":    true, // Original port
+	"x-forwarded-prefix":  true, // Original path prefix
+	"forwarded":           true, // RFC 7239 proxy information
+	"x-real-ip":           true, // Real client IP
+	"authorization":       true, // Client credentials
+	"proxy-authorization": true, // Proxy credentials
+	"cookie":              true, // Session state
+	"content-length":      true, // Message body length
+	"content-type":        true, // Media type
+	"transfer-encoding":   true, // Transfer encoding method (hop-by-hop)
+	"connection":          true, // Hop-by-hop header list
+	"keep-alive":          true, // Hop-by-hop
+	"proxy-authenticate":  true, // Hop-by-hop
+	"te":                  true, // Hop-by-hop
+	"trailer":             true, // Hop-by-hop
+	"upgrade":             true, // Hop-by-hop protocol switch
 }
 
-// IsProtectedHeader checks if a header name is in the protected headers blacklist.
+// IsProtectedHeader checks if a header name is in the built-in protected headers blacklist.
 // Protected headers cannot be modified by JWT claims to prevent security issues.
 //
 // The check is case-insensitive as HTTP header names are case-insensitive per RFC 7230.
@@ -35,6 +48,75 @@ func IsProtectedHeader(name string) bool {
 	return protectedHeaders[normalized]
 }
 
+// protectedHeaderSet builds the effective protected header set: the built-in
+// list extended with extra, or only extra when replace is true. Names are
+// stored lowercase.
+func protectedHeaderSet(extra []string, replace bool) map[string]bool {
+	set := make(map[string]bool, len(protectedHeaders)+len(extra))
+	if !replace {
+		for name := range protectedHeaders {
+			set[name] = true
+		}
+	}
+	for _, name := range extra {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// matchesHeaderPattern reports whether name matches any of the glob patterns
+// (path.Match syntax), compared case-insensitively.
+func matchesHeaderPattern(name string, patterns []string) bool {
+	lower := strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), lower); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeaderToken reports whether name is a valid header field name: an RFC 7230
+// token of visible ASCII characters excluding separators such as spaces,
+// colons, quotes and brackets.
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeHeaderName folds a header name the way common proxies do before
+// forwarding it: case-insensitively and with underscores treated as hyphens,
+// so "x_user" and "X-User" normalize to the same name.
+func normalizeHeaderName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// isUnsafeHeaderRune reports whether SanitizeHeaderValue removes r: ASCII
+// control characters, Unicode line and paragraph separators that some parsers
+// treat as line breaks, and bidi controls that can disguise how a value reads.
+func isUnsafeHeaderRune(r rune) bool {
+	switch {
+	case r < 0x20 || r == 0x7F:
+		return true
+	case r == 0x85 || r == 0x2028 || r == 0x2029:
+		return true
+	case r >= 0x202A && r <= 0x202E, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
+
 // SanitizeHeaderValue removes dangerous characters from header values to prevent
 // header injection attacks and enforce size limits.
 //
@@ -42,8 +124,9 @@ func IsProtectedHeader(name string) bool {
 //   1. Enforces maximum header size limit (prevents memory exhaustion)
 //   2. Removes all ASCII control characters (0x00-0x1F, 0x7F)
 //   3. Prevents CRLF injection attacks (\r\n sequences)
-//   4. Handles Unicode control characters (U+000D, U+000A, U+2028, U+2029)
-//   5. Trims leading/trailing whitespace
+//   4. Removes Unicode line breaks (U+0085, U+2028, U+2029)
+//   5. Removes bidi embedding, override and isolate controls (U+202A-U+202E, U+2066-U+2069)
+//   6. Trims leading/trailing whitespace
 //
 // Example:
 //   // Normal value
@@ -63,11 +146,10 @@ func SanitizeHeaderValue(value string, maxSize int) (string, error) {
 		return "", fmt.Errorf("header value exceeds maximum size (%d bytes)", maxSize)
 	}
 
-	// Remove all control characters (0x00-0x1F and 0x7F)
+	// Remove control characters, Unicode line breaks and bidi controls
 	// This prevents header injection attacks via \r\n and other control chars
 	sanitized := strings.Map(func(r rune) rune {
-		// Remove control characters
-		if r < 0x20 || r == 0x7F {
+		if isUnsafeHeaderRune(r) {
 			return -1 // Remove this character
 		}
 		return r
@@ -115,8 +197,13 @@ func SanitizeHeaderValue(value string, maxSize int) (string, error) {
 //   err := InjectHeader(req, "X-User-Id", "new", true, 8192)
 //   // req.Header.Get("X-User-Id") == "new" (replaced)
 func InjectHeader(req *http.Request, name, value string, override bool, maxSize int) error {
+	return injectHeader(req, name, value, override, maxSize, protectedHeaders)
+}
+
+// injectHeader implements InjectHeader against a given protected header set.
+func injectHeader(req *http.Request, name, value string, override bool, maxSize int, protected map[string]bool) error {
 	// Check if header is protected (case-insensitive)
-	if IsProtectedHeader(name) {
+	if protected[strings.ToLower(name)] {
 		// Silently skip protected headers - not an error
 		// Could add logging here if logger available
 		return nil
@@ -139,3 +226,51 @@ func InjectHeader(req *http.Request, name, value string, override bool, maxSize
 	req.Header.Set(name, sanitized)
 	return nil
 }
+
+// InjectHeaderValues adds one header line per value, for array claims
+// formatted with arrayFormat "multi".
+//
+// The same security controls as InjectHeader apply: protected headers are
+// skipped, every value is sanitized, and the values together may not exceed
+// maxSize bytes. An existing header is preserved unless override is true, in
+// which case all of its values are replaced.
+//
+// Example:
+//   err := InjectHeaderValues(req, "X-Role", []string{"admin", "billing, eu"}, false, 8192)
+//   // req.Header.Values("X-Role") == ["admin", "billing, eu"]
+//
+// Returns an error if the combined values exceed maxSize.
+func InjectHeaderValues(req *http.Request, name string, values []string, override bool, maxSize int) error {
+	return injectHeaderValues(req, name, values, override, maxSize, protectedHeaders)
+}
+
+// injectHeaderValues implements InjectHeaderValues against a given protected header set.
+func injectHeaderValues(req *http.Request, name string, values []string, override bool, maxSize int, protected map[string]bool) error {
+	if protected[strings.ToLower(name)] || len(values) == 0 {
+		return nil
+	}
+
+	sanitized := make([]string, 0, len(values))
+	total := 0
+	for _, value := range values {
+		total += len(value)
+		if total > maxSize {
+			return fmt.Errorf("header values exceed maximum size (%d bytes)", maxSize)
+		}
+		s, err := SanitizeHeaderValue(value, maxSize)
+		if err != nil {
+			return err
+		}
+		sanitized = append(sanitized, s)
+	}
+
+	if req.Header.Get(name) != "" && !override {
+		return nil
+	}
+
+	req.Header.Del(name)
+	for _, value := range sanitized {
+		req.Header.Add(name, value)
+	}
+	return nil
+}
@@ -71,6 +71,27 @@ func TestSanitizeHeaderValue_ControlChars(t *testing.T) {
 			expected: "testvalue",
 			wantErr:  false,
 		},
+		{
+			name:     "Unicode line and paragraph separators",
+			input:    "test\u2028value\u2029\u0085",
+			maxSize:  1000,
+			expected: "testvalue",
+			wantErr:  false,
+		},
+		{
+			name:     "bidi override and isolate controls",
+			input:    "\u202eadmin\u202c\u2066user\u2069",
+			maxSize:  1000,
+			expected: "adminuser",
+			wantErr:  false,
+		},
+		{
+			name:     "non-ASCII text preserved",
+			input:    "José Müller",
+			maxSize:  1000,
+			expected: "José Müller",
+			wantErr:  false,
+		},
 		{
 			name:     "whitespace trimming",
 			input:    "  value with spaces  ",
@@ -370,3 +391,141 @@ func TestInjectHeader_Integration(t *testing.T) {
 		t.Error("Header was not overridden when override=true")
 	}
 }
+
+// TestInjectHeaderValues verifies multi-value injection with the InjectHeader security controls
+func TestInjectHeaderValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		existing []string
+		values   []string
+		override bool
+		maxSize  int
+		want     []string
+		wantErr  bool
+	}{
+		{"adds each value", "X-Role", nil, []string{"admin", "billing, eu"}, false, 8192, []string{"admin", "billing, eu"}, false},
+		{"preserves existing", "X-Role", []string{"guest"}, []string{"admin"}, false, 8192, []string{"guest"}, false},
+		{"override replaces all", "X-Role", []string{"guest", "viewer"}, []string{"admin"}, true, 8192, []string{"admin"}, false},
+		{"sanitizes values", "X-Role", nil, []string{"ad\r\nmin"}, false, 8192, []string{"admin"}, false},
+		{"protected header", "Host", nil, []string{"evil.com"}, true, 8192, nil, false},
+		{"empty values", "X-Role", []string{"guest"}, nil, true, 8192, []string{"guest"}, false},
+		{"combined size limit", "X-Role", nil, []string{"aaaa", "bbbb", "cccc"}, false, 10, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			for _, v := range tt.existing {
+				req.Header.Add(tt.header, v)
+			}
+
+			err := InjectHeaderValues(req, tt.header, tt.values, tt.override, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InjectHeaderValues() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := req.Header.Values(tt.header)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Header values = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Header value %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestProtectedHeaderSet verifies extending and replacing the built-in protected headers
+func TestProtectedHeaderSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   []string
+		replace bool
+		header  string
+		want    bool
+	}{
+		{"built-in", nil, false, "Cookie", true},
+		{"extended", []string{"X-Internal-Auth"}, false, "x-internal-auth", true},
+		{"extension keeps built-in", []string{"X-Internal-Auth"}, false, "Host", true},
+		{"replaced", []string{"X-Internal-Auth"}, true, "X-INTERNAL-AUTH", true},
+		{"replacement drops built-in", []string{"X-Internal-Auth"}, true, "X-Forwarded-Prefix", false},
+		{"unprotected", nil, false, "X-User-Id", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := protectedHeaderSet(tt.extra, tt.replace)
+			if got := set[strings.ToLower(tt.header)]; got != tt.want {
+				t.Errorf("protectedHeaderSet()[%q] = %v, want %v", tt.header, got, tt.want)
+			}
+
+			req, _ := http.NewRequest("GET", "http://example.com", nil)
+			if err := injectHeader(req, tt.header, "value", true, 100, set); err != nil {
+				t.Fatalf("injectHeader() unexpected error: %v", err)
+			}
+			if injected := req.Header.Get(tt.header) != ""; injected == tt.want {
+				t.Errorf("injectHeader(%q) injected = %v, want %v", tt.header, injected, !tt.want)
+			}
+		})
+	}
+}
+
+// TestMatchesHeaderPattern verifies case-insensitive header name globs
+func TestMatchesHeaderPattern(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		patterns []string
+		want     bool
+	}{
+		{"prefix glob", "X-Auth-User", []string{"X-Auth-*"}, true},
+		{"case-insensitive", "x-auth-user", []string{"X-AUTH-*"}, true},
+		{"second pattern", "X-Tenant", []string{"X-Auth-*", "X-Tenant"}, true},
+		{"single character", "X-Auth-1", []string{"X-Auth-?"}, true},
+		{"no match", "X-User-Id", []string{"X-Auth-*"}, false},
+		{"no patterns", "X-User-Id", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesHeaderPattern(tt.header, tt.patterns); got != tt.want {
+				t.Errorf("matchesHeaderPattern(%q, %q) = %v, want %v", tt.header, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsHeaderToken(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"X-User-Id", true},
+		{"x_user", true},
+		{"X-Tenant.v2", true},
+		{"!#$%&'*+-.^_`|~", true},
+		{"", false},
+		{"X User", false},
+		{"X-User:", false},
+		{"X-\"User\"", false},
+		{"X-User\r\n", false},
+		{"X-Usér", false},
+	}
+
+	for _, tt := range tests {
+		if got := isHeaderToken(tt.name); got != tt.want {
+			t.Errorf("isHeaderToken(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeHeaderName(t *testing.T) {
+	if got := normalizeHeaderName("X_User-Id"); got != "x-user-id" {
+		t.Errorf("normalizeHeaderName() = %q, want %q", got, "x-user-id")
+	}
+	if normalizeHeaderName("x_user") != normalizeHeaderName("X-User") {
+		t.Error("normalizeHeaderName() should treat '_' and '-' as equivalent")
+	}
+}
@@ -0,0 +1,169 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// jwk is a single JSON Web Key as defined by RFC 7517.
+// Only the members needed to build a verification key are decoded.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA members
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC and OKP members
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	// Symmetric (oct) members
+	K string `json:"k"`
+}
+
+// jwkSet is a JSON Web Key Set document.
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// ParseJWKS decodes a JWKS document into verification keys.
+// Keys intended for encryption ("use": "enc") and keys of unsupported types
+// or curves are skipped, so a provider publishing extra keys does not break
+// verification for the keys this plugin understands.
+//
+// Supported key types:
+//   - RSA (n, e), at least 2048 bits
+//   - EC (crv P-256, P-384, P-521; x, y)
+//   - OKP (crv Ed25519; x)
+//   - oct (k)
+//
+// Returns an error if:
+//   - The document is not valid JSON or has no "keys" array
+//   - A supported key has missing or malformed members
+//   - No usable key remains after filtering
+func ParseJWKS(data []byte) ([]*Key, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS JSON: %v", err)
+	}
+	if set.Keys == nil {
+		return nil, fmt.Errorf("invalid JWKS: missing 'keys' array")
+	}
+
+	var keys []*Key
+	for i, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		key, err := k.toKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %d (kid '%s'): %w", i, k.Kid, err)
+		}
+		if key == nil {
+			continue // unsupported kty or crv
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid JWKS: no usable signing keys")
+	}
+
+	return keys, nil
+}
+
+// toKey converts a JWK into a verification Key.
+// Returns nil, nil for key types this plugin does not support.
+func (k jwk) toKey() (*Key, error) {
+	var material interface{}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E, "e")
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		if n.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key size %d bits is below minimum of %d bits", n.BitLen(), minRSAKeyBits)
+		}
+		material = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeJWKInt(k.X, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y, "y")
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on curve %s", k.Crv)
+		}
+		material = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, nil
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 member 'x'")
+		}
+		material = ed25519.PublicKey(x)
+
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid symmetric member 'k'")
+		}
+		material = secret
+
+	default:
+		return nil, nil
+	}
+
+	return &Key{ID: k.Kid, Algorithm: k.Alg, material: material}, nil
+}
+
+// decodeJWKInt decodes a base64url-encoded big-endian unsigned integer member.
+func decodeJWKInt(value, member string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing member '%s'", member)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid member '%s': %v", member, err)
+	}
+	return new(big.Int).SetBytes(data), nil
+}
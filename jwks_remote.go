@@ -0,0 +1,241 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxJWKSResponseSize caps the JWKS document size read from the network (1 MiB).
+const maxJWKSResponseSize = 1 << 20
+
+// remoteJWKS fetches and caches a JWKS document from an HTTP endpoint.
+//
+// Behavior:
+//   - The first fetch starts in the background when the plugin is created
+//   - Keys are cached for the Cache-Control max-age, or the configured TTL
+//     when the response carries none, and refreshed in the background
+//   - A token with an unknown 'kid' triggers an on-demand refetch, at most
+//     once per minRefetchInterval
+//   - Requests never wait longer than fetchTimeout for a fetch to finish
+//   - On fetch failure the previously cached keys are kept
+//
+// All mutable state is guarded by mu, so a single instance is safe to share
+// across concurrent requests.
+type remoteJWKS struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	fetchTimeout       time.Duration
+	minRefetchInterval time.Duration
+
+	mu        sync.Mutex
+	keys      []*Key
+	expires   time.Time
+	lastFetch time.Time
+	inflight  chan struct{}
+	lastErr   error
+}
+
+// newRemoteJWKS creates a remote key source from the verification configuration.
+// Durations are assumed to have been checked by Config.Validate; empty values
+// fall back to the defaults.
+func newRemoteJWKS(cfg VerificationConfig) *remoteJWKS {
+	ttl := durationOrDefault(cfg.JWKSCacheTTL, defaultJWKSCacheTTL)
+	timeout := durationOrDefault(cfg.JWKSFetchTimeout, defaultJWKSFetchTimeout)
+	minRefetch := durationOrDefault(cfg.JWKSMinRefetchInterval, defaultJWKSMinRefetchInterval)
+
+	return &remoteJWKS{
+		url:                cfg.JWKSURL,
+		client:             &http.Client{Timeout: timeout},
+		ttl:                ttl,
+		fetchTimeout:       timeout,
+		minRefetchInterval: minRefetch,
+	}
+}
+
+// start performs the initial fetch and keeps the cache fresh until ctx is done.
+func (r *remoteJWKS) start(ctx context.Context) {
+	go func() {
+		for {
+			r.wait(ctx, r.fetch())
+
+			r.mu.Lock()
+			next := time.Until(r.expires)
+			if r.lastErr != nil || next < r.minRefetchInterval {
+				next = r.minRefetchInterval
+			}
+			r.mu.Unlock()
+
+			timer := time.NewTimer(next)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+}
+
+// Keys returns the cached keys. When nothing has been cached yet it waits for
+// an in-flight (or newly started) fetch, bounded by fetchTimeout and ctx.
+// A new fetch is started at most once per minRefetchInterval; in between,
+// requests against an empty cache fail immediately with the last error.
+func (r *remoteJWKS) Keys(ctx context.Context) ([]*Key, error) {
+	r.mu.Lock()
+	keys := r.keys
+	limited := r.inflight == nil && time.Since(r.lastFetch) < r.minRefetchInterval
+	r.mu.Unlock()
+
+	if keys != nil {
+		return keys, nil
+	}
+
+	if !limited {
+		r.wait(ctx, r.fetch())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys == nil {
+		if r.lastErr != nil {
+			return nil, fmt.Errorf("JWKS unavailable: %w", r.lastErr)
+		}
+		return nil, fmt.Errorf("JWKS unavailable: fetch did not complete within %s", r.fetchTimeout)
+	}
+	return r.keys, nil
+}
+
+// Refetch triggers an on-demand fetch for an unknown 'kid', rate-limited to
+// one fetch per minRefetchInterval. It reports whether a fetch was performed
+// and waits for it, bounded by fetchTimeout and ctx.
+func (r *remoteJWKS) Refetch(ctx context.Context) bool {
+	r.mu.Lock()
+	limited := r.inflight == nil && time.Since(r.lastFetch) < r.minRefetchInterval
+	r.mu.Unlock()
+
+	if limited {
+		return false
+	}
+
+	r.wait(ctx, r.fetch())
+	return true
+}
+
+// fetch starts a fetch unless one is already in flight and returns a channel
+// closed when that fetch completes.
+func (r *remoteJWKS) fetch() chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.inflight != nil {
+		return r.inflight
+	}
+
+	done := make(chan struct{})
+	r.inflight = done
+	r.lastFetch = time.Now()
+
+	go func() {
+		keys, maxAge, err := r.download()
+
+		r.mu.Lock()
+		r.lastErr = err
+		if err == nil {
+			r.keys = keys
+			ttl := r.ttl
+			if maxAge >= 0 {
+				ttl = maxAge
+			}
+			if ttl < r.minRefetchInterval {
+				ttl = r.minRefetchInterval
+			}
+			r.expires = time.Now().Add(ttl)
+		}
+		r.inflight = nil
+		r.mu.Unlock()
+
+		close(done)
+	}()
+
+	return done
+}
+
+// wait blocks until done is closed, fetchTimeout elapses, or ctx is cancelled.
+func (r *remoteJWKS) wait(ctx context.Context, done chan struct{}) {
+	timer := time.NewTimer(r.fetchTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// download retrieves and parses the JWKS document.
+// Returns the keys and the Cache-Control max-age (-1 when absent).
+func (r *remoteJWKS) download() ([]*Key, time.Duration, error) {
+	resp, err := r.client.Get(r.url)
+	if err != nil {
+		return nil, -1, fmt.Errorf("JWKS fetch failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, -1, fmt.Errorf("JWKS fetch failed: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSResponseSize+1))
+	if err != nil {
+		return nil, -1, fmt.Errorf("JWKS fetch failed: %v", err)
+	}
+	if len(data) > maxJWKSResponseSize {
+		return nil, -1, fmt.Errorf("JWKS response exceeds maximum size (%d bytes)", maxJWKSResponseSize)
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return keys, parseMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// parseMaxAge extracts the max-age directive from a Cache-Control header.
+// "no-cache" and "no-store" are treated as max-age=0 (the minimum refetch
+// interval still applies). Returns -1 when no usable directive is present.
+func parseMaxAge(cacheControl string) time.Duration {
+	maxAge := time.Duration(-1)
+
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+
+		switch {
+		case directive == "no-cache" || directive == "no-store":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(directive, "max-age="), `"`))
+			if err == nil && seconds >= 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	return maxAge
+}
+
+// durationOrDefault parses value, falling back to def when value is empty.
+func durationOrDefault(value, def string) time.Duration {
+	if value == "" {
+		value = def
+	}
+	d, _ := time.ParseDuration(value)
+	return d
+}
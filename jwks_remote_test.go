@@ -0,0 +1,312 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer is a test JWKS endpoint whose key set can be swapped at runtime
+type jwksServer struct {
+	*httptest.Server
+	mu           sync.Mutex
+	keys         []interface{}
+	cacheControl string
+	hits         int32
+}
+
+// newJWKSServer starts a JWKS endpoint serving the given keys
+func newJWKSServer(t *testing.T, keys ...interface{}) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.hits, 1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.cacheControl != "" {
+			w.Header().Set("Cache-Control", s.cacheControl)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setKeys replaces the served key set
+func (s *jwksServer) setKeys(keys ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// newRemotePlugin creates a plugin verifying against jwksURL and records the injected X-User-Id
+func newRemotePlugin(t *testing.T, verification VerificationConfig, gotUserID *string) http.Handler {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+	config.ContinueOnError = false
+	verification.Enabled = true
+	config.Verification = verification
+
+	plugin, err := New(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotUserID = r.Header.Get("X-User-Id")
+		w.WriteHeader(http.StatusOK)
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return plugin
+}
+
+// serveToken runs a request carrying token through plugin and returns the status code
+func serveToken(plugin http.Handler, token string) int {
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	plugin.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+// TestRemoteJWKS_VerifiesToken verifies keys fetched from a JWKS URL are used for verification
+func TestRemoteJWKS_VerifiesToken(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	server := newJWKSServer(t, rsaJWK("k1", &key.PublicKey))
+
+	var userID string
+	plugin := newRemotePlugin(t, VerificationConfig{JWKSURL: server.URL}, &userID)
+
+	token := signRSA(t, "RS256", key, `{"alg":"RS256","kid":"k1"}`, testPayload)
+	if code := serveToken(plugin, token); code != http.StatusOK {
+		t.Fatalf("Status code = %d, want %d", code, http.StatusOK)
+	}
+	if userID != "1234567890" {
+		t.Errorf("X-User-Id = %q, want 1234567890", userID)
+	}
+
+	// A second request is served from cache
+	serveToken(plugin, token)
+	if hits := atomic.LoadInt32(&server.hits); hits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", hits)
+	}
+}
+
+// TestRemoteJWKS_RefetchRateLimited verifies unknown kid refetches honor jwksMinRefetchInterval
+func TestRemoteJWKS_RefetchRateLimited(t *testing.T) {
+	oldKey := testRSAKey(t, 2048)
+	newKey := testRSAKey(t, 3072)
+	server := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+
+	var userID string
+	plugin := newRemotePlugin(t, VerificationConfig{
+		JWKSURL:                server.URL,
+		JWKSMinRefetchInterval: "1h",
+	}, &userID)
+
+	if code := serveToken(plugin, signRSA(t, "RS256", oldKey, `{"alg":"RS256","kid":"old"}`, testPayload)); code != http.StatusOK {
+		t.Fatalf("old key: Status code = %d, want %d", code, http.StatusOK)
+	}
+
+	// Rotate: the IdP now publishes only the new key
+	server.setKeys(rsaJWK("new", &newKey.PublicKey))
+	hitsBefore := atomic.LoadInt32(&server.hits)
+
+	// Within the rate limit an unknown kid cannot trigger a refetch
+	for i := 0; i < 3; i++ {
+		if code := serveToken(plugin, signRSA(t, "RS256", newKey, `{"alg":"RS256","kid":"new"}`, testPayload)); code != http.StatusUnauthorized {
+			t.Errorf("rate-limited: Status code = %d, want %d", code, http.StatusUnauthorized)
+		}
+	}
+	if hits := atomic.LoadInt32(&server.hits); hits != hitsBefore {
+		t.Errorf("JWKS fetched %d extra times within rate limit, want 0", hits-hitsBefore)
+	}
+}
+
+// TestRemoteJWKS_RotationPickedUp verifies an unknown kid refetches once the rate limit allows
+func TestRemoteJWKS_RotationPickedUp(t *testing.T) {
+	oldKey := testRSAKey(t, 2048)
+	newKey := testRSAKey(t, 3072)
+	server := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+
+	var userID string
+	plugin := newRemotePlugin(t, VerificationConfig{
+		JWKSURL:                server.URL,
+		JWKSMinRefetchInterval: "10ms",
+	}, &userID)
+
+	if code := serveToken(plugin, signRSA(t, "RS256", oldKey, `{"alg":"RS256","kid":"old"}`, testPayload)); code != http.StatusOK {
+		t.Fatalf("old key: Status code = %d, want %d", code, http.StatusOK)
+	}
+
+	server.setKeys(rsaJWK("new", &newKey.PublicKey))
+	time.Sleep(20 * time.Millisecond)
+
+	if code := serveToken(plugin, signRSA(t, "RS256", newKey, `{"alg":"RS256","kid":"new"}`, testPayload)); code != http.StatusOK {
+		t.Errorf("new key: Status code = %d, want %d", code, http.StatusOK)
+	}
+}
+
+// TestRemoteJWKS_RotationWithStaticKey verifies a rotated kid is refetched
+// even when a static key without a kid could be tried instead
+func TestRemoteJWKS_RotationWithStaticKey(t *testing.T) {
+	oldKey := testRSAKey(t, 2048)
+	newKey := testRSAKey(t, 3072)
+	server := newJWKSServer(t, rsaJWK("old", &oldKey.PublicKey))
+
+	var userID string
+	plugin := newRemotePlugin(t, VerificationConfig{
+		Keys:                   []KeyConfig{{Secret: "static-shared-secret-without-key-id"}},
+		JWKSURL:                server.URL,
+		JWKSMinRefetchInterval: "10ms",
+	}, &userID)
+
+	if code := serveToken(plugin, signRSA(t, "RS256", oldKey, `{"alg":"RS256","kid":"old"}`, testPayload)); code != http.StatusOK {
+		t.Fatalf("old key: Status code = %d, want %d", code, http.StatusOK)
+	}
+
+	server.setKeys(rsaJWK("new", &newKey.PublicKey))
+	time.Sleep(20 * time.Millisecond)
+	hitsBefore := atomic.LoadInt32(&server.hits)
+
+	if code := serveToken(plugin, signRSA(t, "RS256", newKey, `{"alg":"RS256","kid":"new"}`, testPayload)); code != http.StatusOK {
+		t.Errorf("new key: Status code = %d, want %d", code, http.StatusOK)
+	}
+	if hits := atomic.LoadInt32(&server.hits); hits != hitsBefore+1 {
+		t.Errorf("JWKS fetched %d times for rotated kid, want 1", hits-hitsBefore)
+	}
+	if code := serveToken(plugin, signHMAC(t, "HS256", "static-shared-secret-without-key-id", `{"alg":"HS256"}`, testPayload)); code != http.StatusOK {
+		t.Errorf("static key: Status code = %d, want %d", code, http.StatusOK)
+	}
+}
+
+// TestRemoteJWKS_ColdFetchTimeout verifies requests do not block past jwksFetchTimeout
+func TestRemoteJWKS_ColdFetchTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var userID string
+	plugin := newRemotePlugin(t, VerificationConfig{
+		JWKSURL:          server.URL,
+		JWKSFetchTimeout: "100ms",
+	}, &userID)
+
+	key := testRSAKey(t, 2048)
+	start := time.Now()
+	code := serveToken(plugin, signRSA(t, "RS256", key, `{"alg":"RS256","kid":"k1"}`, testPayload))
+	elapsed := time.Since(start)
+
+	if code != http.StatusUnauthorized {
+		t.Errorf("Status code = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if elapsed > time.Second {
+		t.Errorf("ServeHTTP blocked for %s, want at most ~100ms", elapsed)
+	}
+}
+
+// TestRemoteJWKS_ColdFetchRateLimited verifies a failing endpoint is not
+// refetched on every request while the cache is empty
+func TestRemoteJWKS_ColdFetchRateLimited(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	r := newRemoteJWKS(VerificationConfig{JWKSURL: server.URL, JWKSMinRefetchInterval: "1h"})
+
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if _, err := r.Keys(context.Background()); err == nil {
+			t.Fatal("Keys() succeeded, want error")
+		}
+		if i > 0 && time.Since(start) > 50*time.Millisecond {
+			t.Errorf("Keys() call %d blocked for %s, want immediate failure", i, time.Since(start))
+		}
+	}
+
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", got)
+	}
+}
+
+// TestRemoteJWKS_CacheControl verifies max-age overrides the configured TTL
+func TestRemoteJWKS_CacheControl(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	server := newJWKSServer(t, rsaJWK("k1", &key.PublicKey))
+	server.cacheControl = "public, max-age=120"
+
+	r := newRemoteJWKS(VerificationConfig{JWKSURL: server.URL, JWKSCacheTTL: "1h"})
+	if _, err := r.Keys(context.Background()); err != nil {
+		t.Fatalf("Keys() failed: %v", err)
+	}
+
+	r.mu.Lock()
+	remaining := time.Until(r.expires)
+	r.mu.Unlock()
+	if remaining > 2*time.Minute || remaining < time.Minute {
+		t.Errorf("cache expires in %s, want ~2m from max-age", remaining)
+	}
+}
+
+// TestRemoteJWKS_FetchErrorKeepsKeys verifies cached keys survive a failed refresh
+func TestRemoteJWKS_FetchErrorKeepsKeys(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	server := newJWKSServer(t, rsaJWK("k1", &key.PublicKey))
+
+	r := newRemoteJWKS(VerificationConfig{JWKSURL: server.URL, JWKSMinRefetchInterval: "1ms"})
+	if _, err := r.Keys(context.Background()); err != nil {
+		t.Fatalf("Keys() failed: %v", err)
+	}
+
+	server.setKeys() // empty key set is not a usable JWKS
+	time.Sleep(5 * time.Millisecond)
+	if !r.Refetch(context.Background()) {
+		t.Fatal("Refetch() was rate-limited, want fetch")
+	}
+
+	keys, err := r.Keys(context.Background())
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Keys() = %d keys, err %v; want cached key retained", len(keys), err)
+	}
+}
+
+// TestParseMaxAge verifies Cache-Control max-age parsing
+func TestParseMaxAge(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", -1},
+		{"public", -1},
+		{"max-age=300", 300 * time.Second},
+		{"public, max-age=60, must-revalidate", 60 * time.Second},
+		{"Max-Age=10", 10 * time.Second},
+		{"no-cache", 0},
+		{"max-age=60, no-store", 0},
+		{"max-age=abc", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := parseMaxAge(tt.header); got != tt.want {
+				t.Errorf("parseMaxAge(%q) = %s, want %s", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,23 +1,26 @@
 // Package traefik_jwt_decoder_plugin implements a Traefik middleware that extracts
 // claims from JWT tokens and injects them as HTTP headers for upstream services.
 //
-// This plugin performs JWT parsing without signature verification. It is designed
-// for internal service-to-service communication where JWT validation occurs at the
-// edge (API gateway) and internal services need access to JWT claims.
+// By default this plugin performs JWT parsing without signature verification. It is
+// designed for internal service-to-service communication where JWT validation occurs
+// at the edge (API gateway) and internal services need access to JWT claims.
 //
-// Security Warning: This plugin does NOT verify JWT signatures. Deploy only behind
+// Security Warning: Unless verification is enabled in the configuration, this plugin
+// does NOT verify JWT signatures. Without verification, deploy only behind
 // authenticated API gateways in trusted internal networks.
 package traefik_jwt_decoder_plugin
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 )
 
 // JWT represents a parsed JWT token with decoded header and payload sections.
-// The signature is stored as a string but is NOT verified by this plugin.
+// The signature is stored as a string; see VerifySignature for optional verification.
 type JWT struct {
 	// Header contains decoded JWT header claims (typ, alg, kid, etc.)
 	Header map[string]interface{}
@@ -27,6 +30,10 @@ type JWT struct {
 
 	// Signature is the base64url-encoded signature (not decoded or verified)
 	Signature string
+
+	// SigningInput is the "header.payload" portion of the token that the
+	// signature was computed over, retained for optional verification
+	SigningInput string
 }
 
 // ParseJWT decodes a JWT token without signature verification.
@@ -41,6 +48,9 @@ type JWT struct {
 // It should only be used in trusted internal networks where
 // signature validation happens at the API gateway.
 //
+// Numbers are decoded as json.Number so that their exact text is preserved;
+// 64-bit IDs such as 9007199254740993 would otherwise be rounded to float64.
+//
 // Strict Mode: When enabled, validates JWT header contains required 'alg' field.
 // This helps detect malformed tokens that might indicate attacks or misconfigurations.
 //
@@ -76,14 +86,14 @@ func ParseJWT(token string, strictMode bool) (*JWT, error) {
 	}
 
 	// Parse header JSON
-	var header map[string]interface{}
-	if err := json.Unmarshal(headerBytes, &header); err != nil {
+	header, err := decodeJSONObject(headerBytes)
+	if err != nil {
 		return nil, fmt.Errorf("invalid JWT JSON: %v", err)
 	}
 
 	// Parse payload JSON
-	var payload map[string]interface{}
-	if err := json.Unmarshal(payloadBytes, &payload); err != nil {
+	payload, err := decodeJSONObject(payloadBytes)
+	if err != nil {
 		return nil, fmt.Errorf("invalid JWT JSON: %v", err)
 	}
 
@@ -96,9 +106,10 @@ func ParseJWT(token string, strictMode bool) (*JWT, error) {
 
 	// Return JWT struct with signature as-is (not decoded)
 	return &JWT{
-		Header:    header,
-		Payload:   payload,
-		Signature: segments[2],
+		Header:       header,
+		Payload:      payload,
+		Signature:    segments[2],
+		SigningInput: segments[0] + "." + segments[1],
 	}, nil
 }
 
@@ -129,3 +140,19 @@ func ExtractToken(value, prefix string) string {
 	// Strip prefix and trim whitespace
 	return strings.TrimSpace(strings.TrimPrefix(value, prefix))
 }
+
+// decodeJSONObject decodes a JSON object, keeping numbers as json.Number.
+// Like json.Unmarshal, it rejects trailing data after the object.
+func decodeJSONObject(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid character after top-level value")
+	}
+	return object, nil
+}
@@ -3,14 +3,20 @@ package traefik_jwt_decoder_plugin
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"text/template"
+	"time"
 )
 
 // JWTClaimsHeaders is the main plugin struct implementing the http.Handler interface.
 // It orchestrates JWT parsing, claim extraction, and header injection for each request.
 //
 // The struct is immutable after creation, making it thread-safe for concurrent requests.
+// The only mutable state, the remote JWKS cache, synchronizes internally.
 type JWTClaimsHeaders struct {
 	// next is the next HTTP handler in the Traefik middleware chain
 	next http.Handler
@@ -20,6 +26,40 @@ type JWTClaimsHeaders struct {
 
 	// name is the plugin instance name for logging
 	name string
+
+	// keys holds the signature verification keys (nil when verification is disabled)
+	keys *KeySet
+
+	// jwks is the remote key source (nil when no jwksURL is configured)
+	jwks *remoteJWKS
+
+	// clockSkew and maxTokenAge are the parsed time claim settings
+	clockSkew   time.Duration
+	maxTokenAge time.Duration
+
+	// rules are the compiled claim assertions from the configuration
+	rules []*compiledRule
+
+	// policies are the compiled method/path scoped rules from the configuration
+	policies []*compiledPolicy
+
+	// transforms holds the compiled transform pipeline of each claim mapping,
+	// indexed like config.Claims
+	transforms [][]*compiledTransform
+
+	// templates holds the parsed template of each claim mapping, indexed like
+	// config.Claims (nil for claimPath mappings)
+	templates []*template.Template
+
+	// protected is the effective protected header set (lowercase names)
+	protected map[string]bool
+
+	// stripNames holds the normalized names of every header this plugin
+	// injects, removed from inbound requests with stripIncomingHeaders
+	stripNames map[string]bool
+
+	// now returns the current time; replaceable in tests for deterministic time checks
+	now func() time.Time
 }
 
 // shouldLog determines if a message at the given level should be logged
@@ -47,7 +87,7 @@ func (j *JWTClaimsHeaders) shouldLog(level string) bool {
 // If validation fails, an error is returned and the plugin won't be loaded.
 //
 // Parameters:
-//   - ctx: Context for initialization (currently unused)
+//   - ctx: Context for initialization; bounds the remote JWKS refresh loop
 //   - next: Next handler in the middleware chain
 //   - config: Plugin configuration (will be validated)
 //   - name: Plugin instance name for logging
@@ -58,11 +98,70 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 		return nil, err
 	}
 
-	return &JWTClaimsHeaders{
-		next:   next,
-		config: config,
-		name:   name,
-	}, nil
+	plugin := &JWTClaimsHeaders{
+		next:      next,
+		config:    config,
+		name:      name,
+		protected: protectedHeaderSet(config.ProtectedHeaders, config.ReplaceProtectedHeaders),
+		now:       time.Now,
+	}
+
+	plugin.stripNames = make(map[string]bool, len(config.Claims)+1)
+	for _, claimMapping := range config.Claims {
+		plugin.stripNames[normalizeHeaderName(claimMapping.HeaderName)] = true
+	}
+	if config.PayloadHeader.HeaderName != "" {
+		plugin.stripNames[normalizeHeaderName(config.PayloadHeader.HeaderName)] = true
+	}
+
+	rules, err := compileRules(config.Rules)
+	if err != nil {
+		return nil, err
+	}
+	plugin.rules = rules
+
+	policies, err := compilePolicies(config.Policies)
+	if err != nil {
+		return nil, err
+	}
+	plugin.policies = policies
+
+	plugin.transforms = make([][]*compiledTransform, len(config.Claims))
+	plugin.templates = make([]*template.Template, len(config.Claims))
+	for i, claimMapping := range config.Claims {
+		transforms, err := compileTransforms(claimMapping.Transforms)
+		if err != nil {
+			return nil, err
+		}
+		plugin.transforms[i] = transforms
+
+		if claimMapping.Template != "" {
+			tmpl, err := compileTemplate(claimMapping.Template)
+			if err != nil {
+				return nil, err
+			}
+			plugin.templates[i] = tmpl
+		}
+	}
+
+	// Durations were checked by Validate; empty values leave the zero default
+	plugin.clockSkew, _ = time.ParseDuration(config.ClockSkew)
+	plugin.maxTokenAge, _ = time.ParseDuration(config.MaxTokenAge)
+
+	if config.Verification.Enabled {
+		keys, err := LoadKeySet(config.Verification)
+		if err != nil {
+			return nil, fmt.Errorf("verification: %w", err)
+		}
+		plugin.keys = keys
+
+		if config.Verification.JWKSURL != "" {
+			plugin.jwks = newRemoteJWKS(config.Verification)
+			plugin.jwks.start(ctx)
+		}
+	}
+
+	return plugin, nil
 }
 
 // ServeHTTP implements the http.Handler interface to process each HTTP request.
@@ -71,28 +170,45 @@ func New(ctx context.Context, next http.Handler, config *Config, name string) (h
 // Request Processing Flow:
 //   1. Extract JWT from source header
 //   2. Parse JWT (base64url decode, JSON unmarshal)
-//   3. For each claim mapping:
-//      a. Try extracting claim from configured sections
+//   3. Check algorithm allow-list and verify signature (when enabled)
+//   4. Validate exp, nbf, iat, iss and aud (when configured)
+//   5. Check required claims are present and convertible
+//   6. Evaluate authorization rules and matching policies (403 on failure)
+//   7. Check required OAuth2 scopes (403 insufficient_scope on failure)
+//   8. Inject the full payload header (when configured)
+//   9. For each claim mapping:
+//      a. Render its template, or extract the claim (then its fallbackPaths, then
+//         its default) from configured sections
 //      b. Convert claim value to string
-//      c. Inject as HTTP header (with security guards)
-//   4. Optionally remove source header
-//   5. Forward request to next handler
+//      c. Apply the mapping's transforms in order
+//      d. Apply the mapping's encoding
+//      e. Inject as HTTP header (with security guards)
+//   10. Optionally remove source header
+//   11. Forward request to next handler
 //
 // Error Handling:
-//   - If continueOnError=true: Log errors and pass request through
+//   - If continueOnError=true: Log errors and pass request through, unless
+//     rules, required scopes or a matching policy need a token (401)
 //   - If continueOnError=false: Return 401 Unauthorized with JSON error body
+//   - Failed authorization rules and policies always return 403 Forbidden
 //
 // Thread Safety:
 //   - All data flows through function parameters (no shared state)
 //   - Safe for concurrent execution across multiple requests
 func (j *JWTClaimsHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	// 0. Remove client-supplied copies of the headers this plugin injects,
+	// before any early return can forward the request
+	if j.config.StripIncomingHeaders {
+		j.stripIncomingHeaders(req)
+	}
+
 	// 1. Extract JWT from source header
 	headerValue := req.Header.Get(j.config.SourceHeader)
 	if headerValue == "" {
 		if j.shouldLog("warn") {
 			log.Printf("[%s] JWT source header not found: %s", j.name, j.config.SourceHeader)
 		}
-		if j.config.ContinueOnError {
+		if j.passThrough(req) {
 			j.next.ServeHTTP(rw, req)
 			return
 		}
@@ -109,7 +225,7 @@ func (j *JWTClaimsHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 		if j.shouldLog("error") {
 			log.Printf("[%s] JWT parse error: %v", j.name, err)
 		}
-		if j.config.ContinueOnError {
+		if j.passThrough(req) {
 			j.next.ServeHTTP(rw, req)
 			return
 		}
@@ -117,47 +233,166 @@ func (j *JWTClaimsHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	// 4. Process each claim mapping
-	for _, claimMapping := range j.config.Claims {
-		// Determine which sections to search
-		var claimValue interface{}
-		var found bool
-
-		for _, section := range j.config.Sections {
-			var data map[string]interface{}
-			if section == "payload" {
-				data = jwt.Payload
-			} else if section == "header" {
-				data = jwt.Header
+	// 4. Enforce the algorithm allow-list, then verify the signature
+	// before trusting any claim
+	if err := CheckAlgorithm(jwt, j.config.AllowedAlgorithms); err != nil {
+		if j.shouldLog("error") {
+			log.Printf("[%s] JWT algorithm rejected: %v", j.name, err)
+		}
+		if j.passThrough(req) {
+			j.next.ServeHTTP(rw, req)
+			return
+		}
+		j.returnError(rw, "unauthorized", "JWT algorithm not allowed")
+		return
+	}
+
+	if j.keys != nil {
+		if err := j.verifySignature(req.Context(), jwt); err != nil {
+			if j.shouldLog("error") {
+				log.Printf("[%s] JWT verification error: %v", j.name, err)
+			}
+			if j.passThrough(req) {
+				j.next.ServeHTTP(rw, req)
+				return
+			}
+			switch {
+			case errors.Is(err, ErrUnknownKeyID):
+				j.returnError(rw, "unauthorized", "unknown JWT signing key")
+			case errors.Is(err, ErrAlgorithmNotAllowed):
+				j.returnError(rw, "unauthorized", "JWT algorithm not allowed")
+			default:
+				j.returnError(rw, "unauthorized", "invalid JWT signature")
 			}
+			return
+		}
+	}
+
+	// 5. Validate registered claims (exp, nbf, iat, iss, aud)
+	if err := j.validateRegisteredClaims(jwt); err != nil {
+		if j.shouldLog("warn") {
+			log.Printf("[%s] JWT claim rejected: %v", j.name, err)
+		}
+		if j.passThrough(req) {
+			j.next.ServeHTTP(rw, req)
+			return
+		}
+		j.returnError(rw, "unauthorized", err.Error())
+		return
+	}
+
+	// 6. Enforce required claims before injecting anything
+	if missing := j.missingRequiredClaims(jwt); len(missing) > 0 {
+		if j.shouldLog("warn") {
+			log.Printf("[%s] Required claims missing: %s", j.name, strings.Join(missing, ", "))
+		}
+		if !j.config.ContinueOnError {
+			j.writeError(rw, http.StatusUnauthorized, "unauthorized", "missing required claims", map[string]interface{}{
+				"missingClaims": missing,
+			})
+			return
+		}
+	}
 
-			// Try to extract claim from this section
-			value, err := ExtractClaim(data, claimMapping.ClaimPath, j.config.MaxClaimDepth)
-			if err == nil {
-				claimValue = value
-				found = true
-				break // Found in this section, stop searching
+	// 7. Evaluate authorization rules and request-scoped policies
+	if rule := j.failedRule(jwt, j.rules); rule != nil {
+		if j.shouldLog("warn") {
+			log.Printf("[%s] Rule failed: %s %s", j.name, rule.rule.ClaimPath, rule.rule.Operator)
+		}
+		j.writeError(rw, http.StatusForbidden, "forbidden", rule.message(), nil)
+		return
+	}
+
+	for _, policy := range j.policies {
+		if !policy.matches(req) {
+			continue
+		}
+		if rule := j.failedRule(jwt, policy.rules); rule != nil {
+			if j.shouldLog("warn") {
+				log.Printf("[%s] Policy denied %s %s: %s %s", j.name, req.Method, req.URL.Path, rule.rule.ClaimPath, rule.rule.Operator)
 			}
+			j.writeError(rw, http.StatusForbidden, "forbidden", policy.message(rule), nil)
+			return
 		}
+	}
 
-		if !found {
-			if j.config.LogMissingClaims && j.shouldLog("warn") {
-				log.Printf("[%s] Claim not found: %s", j.name, claimMapping.ClaimPath)
+	// 8. Enforce required OAuth2 scopes
+	if missing := CheckScopes(j.grantedScopes(jwt), j.config.RequiredScopes, j.config.RequiredAnyScopes); missing != nil {
+		if j.shouldLog("warn") {
+			log.Printf("[%s] Insufficient scope: missing %s", j.name, strings.Join(missing, " "))
+		}
+		scope := strings.Join(append(append([]string{}, j.config.RequiredScopes...), j.config.RequiredAnyScopes...), " ")
+		rw.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope))
+		j.writeError(rw, http.StatusForbidden, "insufficient_scope", "token does not grant the required scopes", map[string]interface{}{
+			"scope": scope,
+		})
+		return
+	}
+
+	// 9. Inject the full payload header; an oversized payload fails the
+	// request like any other token error
+	if j.config.PayloadHeader.HeaderName != "" {
+		if err := j.injectPayloadHeader(req, jwt); err != nil {
+			if j.shouldLog("error") {
+				log.Printf("[%s] Failed to inject header %s: %v", j.name, j.config.PayloadHeader.HeaderName, err)
 			}
+			if !j.config.ContinueOnError {
+				j.returnError(rw, "unauthorized", "JWT payload exceeds maxHeaderSize")
+				return
+			}
+		}
+	}
+
+	// 10. Process each claim mapping
+	for i, claimMapping := range j.config.Claims {
+		var values []string
+		isDefault := false
+		if j.templates[i] != nil {
+			rendered, err := renderTemplate(j.templates[i], jwt, j.config.MaxHeaderSize)
+			if err != nil {
+				if j.shouldLog("warn") {
+					log.Printf("[%s] Failed to render template for header %s: %v", j.name, claimMapping.HeaderName, err)
+				}
+				continue
+			}
+			values = []string{rendered}
+		} else if claimValues, ok := j.claimValues(jwt, claimMapping); ok {
+			values = claimValues
+		} else if claimMapping.Default != "" {
+			// Defaults are injected as configured, without transforms
+			values, isDefault = []string{claimMapping.Default}, true
+		} else {
 			continue // Skip this mapping
 		}
 
-		// Convert claim to string
-		strValue, err := ConvertClaimToString(claimValue, claimMapping.ArrayFormat)
+		// Apply value transforms
+		if !isDefault {
+			transformed, err := transformValues(j.transforms[i], values)
+			if err != nil {
+				if j.shouldLog("warn") {
+					log.Printf("[%s] Failed to transform value for header %s: %v", j.name, claimMapping.HeaderName, err)
+				}
+				continue
+			}
+			values = transformed
+		}
+
+		// Encode values for backends that mishandle non-ASCII bytes
+		encoded, err := encodeHeaderValues(values, claimMapping.Encoding)
 		if err != nil {
-			if j.shouldLog("error") {
-				log.Printf("[%s] Failed to convert claim %s: %v", j.name, claimMapping.ClaimPath, err)
+			if j.shouldLog("warn") {
+				log.Printf("[%s] Failed to encode value for header %s: %v", j.name, claimMapping.HeaderName, err)
 			}
 			continue
 		}
+		values = encoded
 
-		// Inject header
-		err = InjectHeader(req, claimMapping.HeaderName, strValue, claimMapping.Override, j.config.MaxHeaderSize)
+		// Inject header: one line per value for "multi", otherwise a single value
+		if claimMapping.ArrayFormat == "multi" {
+			err = injectHeaderValues(req, claimMapping.HeaderName, values, claimMapping.Override, j.config.MaxHeaderSize, j.protected)
+		} else {
+			err = injectHeader(req, claimMapping.HeaderName, values[0], claimMapping.Override, j.config.MaxHeaderSize, j.protected)
+		}
 		if err != nil {
 			if j.shouldLog("error") {
 				log.Printf("[%s] Failed to inject header %s: %v", j.name, claimMapping.HeaderName, err)
@@ -166,19 +401,267 @@ func (j *JWTClaimsHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 		}
 
 		if j.shouldLog("debug") {
-			log.Printf("[%s] Injected header: %s = %s", j.name, claimMapping.HeaderName, strValue)
+			log.Printf("[%s] Injected header: %s = %s", j.name, claimMapping.HeaderName, strings.Join(values, ", "))
 		}
 	}
 
-	// 5. Remove source header if configured
+	// 11. Remove source header if configured
 	if j.config.RemoveSourceHeader {
 		req.Header.Del(j.config.SourceHeader)
 	}
 
-	// 6. Forward to next handler
+	// 12. Forward to next handler
 	j.next.ServeHTTP(rw, req)
 }
 
+// passThrough reports whether a request without a usable token may continue
+// to the next handler. It requires continueOnError, and fails closed when
+// rules or required scopes are configured or a policy matches the request,
+// since those checks cannot pass without a token.
+func (j *JWTClaimsHeaders) passThrough(req *http.Request) bool {
+	if !j.config.ContinueOnError {
+		return false
+	}
+	if len(j.rules) > 0 || len(j.config.RequiredScopes) > 0 || len(j.config.RequiredAnyScopes) > 0 {
+		return false
+	}
+	for _, policy := range j.policies {
+		if policy.matches(req) {
+			return false
+		}
+	}
+	return true
+}
+
+// stripIncomingHeaders deletes every configured headerName, the payload
+// header, and headers matching stripHeaderPrefix, from the request.
+// Names are compared after normalizeHeaderName, so spellings such as
+// "X_User_Id" or a raw lowercase key are stripped as well, since many
+// backends read them as the same header. Protected headers are kept.
+func (j *JWTClaimsHeaders) stripIncomingHeaders(req *http.Request) {
+	prefix := normalizeHeaderName(j.config.StripHeaderPrefix)
+	for name := range req.Header {
+		if j.protected[strings.ToLower(name)] {
+			continue
+		}
+		normalized := normalizeHeaderName(name)
+		if j.stripNames[normalized] || (prefix != "" && strings.HasPrefix(normalized, prefix)) {
+			if j.shouldLog("debug") {
+				log.Printf("[%s] Stripped incoming header: %s", j.name, name)
+			}
+			delete(req.Header, name)
+		}
+	}
+}
+
+// injectPayloadHeader writes the token's claims to the configured payload
+// header. Returns an error when the encoded value exceeds maxHeaderSize.
+func (j *JWTClaimsHeaders) injectPayloadHeader(req *http.Request, jwt *JWT) error {
+	cfg := j.config.PayloadHeader
+	value, err := EncodePayloadHeader(jwt, cfg, j.config.MaxHeaderSize)
+	if err != nil {
+		return err
+	}
+	if err := injectHeader(req, cfg.HeaderName, value, cfg.Override, j.config.MaxHeaderSize, j.protected); err != nil {
+		return err
+	}
+	if j.shouldLog("debug") {
+		log.Printf("[%s] Injected header: %s (%d bytes)", j.name, cfg.HeaderName, len(value))
+	}
+	return nil
+}
+
+// claimValues looks up a mapping's claim and converts it to header values
+// (several only for arrayFormat "multi"), logging missing and unconvertible
+// claims. Reports false when no value is available.
+func (j *JWTClaimsHeaders) claimValues(jwt *JWT, claimMapping ClaimMapping) ([]string, bool) {
+	claimValue, path, found := j.lookupMapping(jwt, claimMapping)
+	if !found {
+		if j.config.LogMissingClaims && j.shouldLog("warn") {
+			log.Printf("[%s] Claim not found: %s", j.name, claimMapping.ClaimPath)
+		}
+		return nil, false
+	}
+
+	if claimMapping.Scopes {
+		if scopes, err := ParseScopes(claimValue); err == nil {
+			claimValue = scopesToArray(scopes)
+		}
+	}
+
+	// Convert claim to strings
+	values, err := mappingStrings(claimValue, claimMapping, j.config.MaxHeaderSize)
+	if err != nil {
+		if j.shouldLog("error") {
+			log.Printf("[%s] Failed to convert claim %s: %v", j.name, path, err)
+		}
+		return nil, false
+	}
+	return values, true
+}
+
+// mappingStrings applies a mapping's numberFormat and arrayFormat to a claim.
+// Formatted numbers are limited to maxSize bytes.
+func mappingStrings(value interface{}, claimMapping ClaimMapping, maxSize int) ([]string, error) {
+	value, err := FormatClaimNumbers(value, claimMapping.NumberFormat, claimMapping.Decimals, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertClaimToStrings(value, claimMapping.ArrayFormat, claimMapping.ArraySeparator)
+}
+
+// lookupMapping tries a mapping's claimPath and then each of its
+// fallbackPaths, returning the first claim found and the path that matched.
+func (j *JWTClaimsHeaders) lookupMapping(jwt *JWT, claimMapping ClaimMapping) (interface{}, string, bool) {
+	if value, found := j.lookupClaim(jwt, claimMapping.ClaimPath, claimMapping.PathSyntax); found {
+		return value, claimMapping.ClaimPath, true
+	}
+	for _, path := range claimMapping.FallbackPaths {
+		if value, found := j.lookupClaim(jwt, path, claimMapping.PathSyntax); found {
+			return value, path, true
+		}
+	}
+	return nil, "", false
+}
+
+// lookupClaim extracts a claim from the configured sections in order,
+// returning the first match. syntax selects dot paths ("" or "dot") or
+// JSON Pointers ("pointer").
+func (j *JWTClaimsHeaders) lookupClaim(jwt *JWT, path, syntax string) (interface{}, bool) {
+	for _, section := range j.config.Sections {
+		var data map[string]interface{}
+		if section == "payload" {
+			data = jwt.Payload
+		} else if section == "header" {
+			data = jwt.Header
+		}
+
+		// Try to extract claim from this section
+		var value interface{}
+		var err error
+		if syntax == pathSyntaxPointer {
+			value, err = ExtractClaimPointer(data, path, j.config.MaxClaimDepth)
+		} else {
+			value, err = ExtractClaim(data, path, j.config.MaxClaimDepth)
+		}
+		if err == nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// missingRequiredClaims returns the paths of required claims that are absent
+// from the token. For mappings marked required, a claim whose value cannot be
+// converted to a header string also counts as missing; any of the mapping's
+// fallbackPaths satisfies it.
+func (j *JWTClaimsHeaders) missingRequiredClaims(jwt *JWT) []string {
+	var missing []string
+	seen := make(map[string]bool)
+
+	report := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			missing = append(missing, path)
+		}
+	}
+
+	for _, path := range j.config.RequiredClaims {
+		if _, found := j.lookupClaim(jwt, path, pathSyntaxDot); !found {
+			report(path)
+		}
+	}
+
+	for _, claimMapping := range j.config.Claims {
+		if !claimMapping.Required {
+			continue
+		}
+		value, _, found := j.lookupMapping(jwt, claimMapping)
+		if !found {
+			report(claimMapping.ClaimPath)
+			continue
+		}
+		if _, err := mappingStrings(value, claimMapping, j.config.MaxHeaderSize); err != nil {
+			report(claimMapping.ClaimPath)
+		}
+	}
+
+	return missing
+}
+
+// failedRule returns the first of rules the token does not satisfy,
+// or nil when all rules pass.
+func (j *JWTClaimsHeaders) failedRule(jwt *JWT, rules []*compiledRule) *compiledRule {
+	for _, rule := range rules {
+		value, found := j.lookupClaim(jwt, rule.rule.ClaimPath, pathSyntaxDot)
+		if !rule.evaluate(value, found) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// grantedScopes collects the OAuth2 scopes from every configured scope claim.
+// Claims that are absent or malformed contribute no scopes.
+func (j *JWTClaimsHeaders) grantedScopes(jwt *JWT) []string {
+	claims := j.config.ScopeClaims
+	if len(claims) == 0 {
+		claims = defaultScopeClaims
+	}
+
+	var granted []string
+	for _, path := range claims {
+		value, found := j.lookupClaim(jwt, path, pathSyntaxDot)
+		if !found {
+			continue
+		}
+		scopes, err := ParseScopes(value)
+		if err != nil {
+			continue
+		}
+		granted = append(granted, scopes...)
+	}
+	return granted
+}
+
+// verifySignature verifies the token against the static keys and, when a
+// jwksURL is configured, the cached remote keys. A 'kid' that matches no key
+// triggers a rate-limited refetch of the remote JWKS before verification, so
+// rotated keys are picked up even when kid-less static keys would otherwise
+// be tried in their place.
+func (j *JWTClaimsHeaders) verifySignature(ctx context.Context, jwt *JWT) error {
+	if j.jwks == nil {
+		return VerifySignature(jwt, j.keys)
+	}
+
+	remote, err := j.jwks.Keys(ctx)
+	if err != nil && len(j.keys.keys) == 0 {
+		return err
+	}
+
+	keys := j.keys.withKeys(remote)
+	kid, _ := jwt.Header["kid"].(string)
+	if kid != "" && !keys.hasKeyID(kid) && j.jwks.Refetch(ctx) {
+		remote, _ = j.jwks.Keys(ctx)
+		keys = j.keys.withKeys(remote)
+	}
+	return VerifySignature(jwt, keys)
+}
+
+// validateRegisteredClaims applies the configured time, issuer and audience
+// checks to the payload. Each check is skipped when not configured.
+func (j *JWTClaimsHeaders) validateRegisteredClaims(jwt *JWT) error {
+	if j.config.ValidateTimeClaims {
+		if err := ValidateTimeClaims(jwt.Payload, j.now(), j.clockSkew, j.maxTokenAge); err != nil {
+			return err
+		}
+	}
+	if err := ValidateIssuer(jwt.Payload, j.config.AllowedIssuers); err != nil {
+		return err
+	}
+	return ValidateAudience(jwt.Payload, j.config.AllowedAudiences)
+}
+
 // returnError sends a JSON error response with 401 Unauthorized status.
 // Used when continueOnError=false and JWT processing fails.
 //
@@ -193,13 +676,23 @@ func (j *JWTClaimsHeaders) ServeHTTP(rw http.ResponseWriter, req *http.Request)
 //   - errorType: Error type identifier (e.g., "unauthorized")
 //   - message: Human-readable error message
 func (j *JWTClaimsHeaders) returnError(rw http.ResponseWriter, errorType, message string) {
+	j.writeError(rw, http.StatusUnauthorized, errorType, message, nil)
+}
+
+// writeError sends a JSON error response with the given status code.
+// Entries in details are added to the response body alongside "error" and
+// "message", e.g. {"missingClaims": ["sub"]}.
+func (j *JWTClaimsHeaders) writeError(rw http.ResponseWriter, status int, errorType, message string, details map[string]interface{}) {
 	rw.Header().Set("Content-Type", "application/json")
-	rw.WriteHeader(http.StatusUnauthorized)
+	rw.WriteHeader(status)
 
-	errorResponse := map[string]string{
+	errorResponse := map[string]interface{}{
 		"error":   errorType,
 		"message": message,
 	}
+	for key, value := range details {
+		errorResponse[key] = value
+	}
 
 	if err := json.NewEncoder(rw).Encode(errorResponse); err != nil {
 		log.Printf("[%s] Failed to encode error response: %v", j.name, err)
@@ -2,11 +2,14 @@ package traefik_jwt_decoder_plugin
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 // validTestToken is the standard test JWT from CLAUDE.md
@@ -591,3 +594,974 @@ func TestServeHTTP_ArrayFormatJSON(t *testing.T) {
 		t.Errorf("Status code = %d, want %d", rr.Code, http.StatusOK)
 	}
 }
+
+// TestServeHTTP_SignatureVerification verifies tokens are verified before claims are injected
+func TestServeHTTP_SignatureVerification(t *testing.T) {
+	validToken := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256","typ":"JWT"}`, testPayload)
+	forgedToken := signHMAC(t, "HS256", "attacker-secret", `{"alg":"HS256","typ":"JWT"}`, testPayload)
+
+	tests := []struct {
+		name            string
+		token           string
+		continueOnError bool
+		wantStatus      int
+		wantNextCalled  bool
+		wantUserID      string
+	}{
+		{"valid signature", validToken, false, http.StatusOK, true, "1234567890"},
+		{"forged signature - strict", forgedToken, false, http.StatusUnauthorized, false, ""},
+		{"forged signature - continue", forgedToken, true, http.StatusOK, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = tt.continueOnError
+			config.Verification = VerificationConfig{
+				Enabled: true,
+				Keys:    []KeyConfig{{Secret: testHMACSecret}},
+			}
+
+			nextCalled := false
+			var gotUserID string
+			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				gotUserID = r.Header.Get("X-User-Id")
+				w.WriteHeader(http.StatusOK)
+			})
+
+			plugin, err := New(context.Background(), nextHandler, config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if nextCalled != tt.wantNextCalled {
+				t.Errorf("next called = %v, want %v", nextCalled, tt.wantNextCalled)
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("X-User-Id = %q, want %q", gotUserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+// TestServeHTTP_TimeClaims verifies expired tokens stop producing headers, using a fixed clock
+func TestServeHTTP_TimeClaims(t *testing.T) {
+	// validTestToken has iat=1516239022 and no exp
+	issuedAt := time.Unix(1516239022, 0)
+
+	tests := []struct {
+		name            string
+		now             time.Time
+		maxTokenAge     string
+		continueOnError bool
+		wantStatus      int
+		wantUserID      string
+	}{
+		{"fresh token", issuedAt.Add(time.Minute), "1h", false, http.StatusOK, "1234567890"},
+		{"too old - strict", issuedAt.Add(2 * time.Hour), "1h", false, http.StatusUnauthorized, ""},
+		{"too old - continue", issuedAt.Add(2 * time.Hour), "1h", true, http.StatusOK, ""},
+		{"issued in future", issuedAt.Add(-time.Hour), "", false, http.StatusUnauthorized, ""},
+		{"issued in future within skew", issuedAt.Add(-10 * time.Second), "", false, http.StatusOK, "1234567890"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = tt.continueOnError
+			config.ValidateTimeClaims = true
+			config.ClockSkew = "30s"
+			config.MaxTokenAge = tt.maxTokenAge
+
+			var gotUserID string
+			handler, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserID = r.Header.Get("X-User-Id")
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			plugin := handler.(*JWTClaimsHeaders)
+			plugin.now = func() time.Time { return tt.now }
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+validTestToken)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if gotUserID != tt.wantUserID {
+				t.Errorf("X-User-Id = %q, want %q", gotUserID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+// TestServeHTTP_IssuerAudience verifies iss/aud checks with and without signature verification
+func TestServeHTTP_IssuerAudience(t *testing.T) {
+	payload := `{"sub":"42","iss":"https://idp-a.example.com/","aud":["billing-api","reports-api"]}`
+	token := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, payload)
+
+	tests := []struct {
+		name        string
+		verify      bool
+		issuers     []string
+		audiences   []string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"allowed without verification", false, []string{"https://idp-a.example.com/"}, []string{"reports-api"}, http.StatusOK, ""},
+		{"allowed with verification", true, []string{"https://idp-a.example.com/"}, []string{"reports-api"}, http.StatusOK, ""},
+		{"wrong issuer", false, []string{"https://idp-b.example.com/"}, nil, http.StatusUnauthorized, "JWT claim 'iss' validation failed: issuer 'https://idp-a.example.com/' is not allowed"},
+		{"wrong audience", true, nil, []string{"admin-api"}, http.StatusUnauthorized, "JWT claim 'aud' validation failed: no allowed audience in token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = false
+			config.AllowedIssuers = tt.issuers
+			config.AllowedAudiences = tt.audiences
+			if tt.verify {
+				config.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: testHMACSecret}}}
+			}
+
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantMessage != "" {
+				var body map[string]string
+				_ = json.NewDecoder(rr.Body).Decode(&body)
+				if body["message"] != tt.wantMessage {
+					t.Errorf("message = %q, want %q", body["message"], tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+// TestServeHTTP_RequiredClaims verifies missing required claims fail the request and are reported
+func TestServeHTTP_RequiredClaims(t *testing.T) {
+	tests := []struct {
+		name            string
+		claims          []ClaimMapping
+		requiredClaims  []string
+		continueOnError bool
+		wantStatus      int
+		wantMissing     []string
+	}{
+		{
+			name: "required claims present",
+			claims: []ClaimMapping{
+				{ClaimPath: "sub", HeaderName: "X-User-Id", Required: true},
+				{ClaimPath: "custom.tenant_id", HeaderName: "X-Tenant-Id", Required: true},
+			},
+			requiredClaims: []string{"email"},
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name: "required mapping missing",
+			claims: []ClaimMapping{
+				{ClaimPath: "sub", HeaderName: "X-User-Id", Required: true},
+				{ClaimPath: "org.id", HeaderName: "X-Org-Id", Required: true},
+				{ClaimPath: "nickname", HeaderName: "X-Nickname"},
+			},
+			wantStatus:  http.StatusUnauthorized,
+			wantMissing: []string{"org.id"},
+		},
+		{
+			name: "global required claims missing",
+			claims: []ClaimMapping{
+				{ClaimPath: "sub", HeaderName: "X-User-Id"},
+			},
+			requiredClaims: []string{"iss", "aud", "email"},
+			wantStatus:     http.StatusUnauthorized,
+			wantMissing:    []string{"iss", "aud"},
+		},
+		{
+			name: "missing required claim with continueOnError",
+			claims: []ClaimMapping{
+				{ClaimPath: "org.id", HeaderName: "X-Org-Id", Required: true},
+			},
+			continueOnError: true,
+			wantStatus:      http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = tt.claims
+			config.RequiredClaims = tt.requiredClaims
+			config.ContinueOnError = tt.continueOnError
+
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+validTestToken)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantMissing == nil {
+				return
+			}
+
+			var body struct {
+				Error         string   `json:"error"`
+				MissingClaims []string `json:"missingClaims"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode error body: %v", err)
+			}
+			if len(body.MissingClaims) != len(tt.wantMissing) {
+				t.Fatalf("missingClaims = %v, want %v", body.MissingClaims, tt.wantMissing)
+			}
+			for i, claim := range tt.wantMissing {
+				if body.MissingClaims[i] != claim {
+					t.Errorf("missingClaims[%d] = %q, want %q", i, body.MissingClaims[i], claim)
+				}
+			}
+		})
+	}
+}
+
+// TestServeHTTP_Rules verifies failed rules return 403 with the configured message
+func TestServeHTTP_Rules(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []Rule
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name: "all rules pass",
+			rules: []Rule{
+				{ClaimPath: "roles", Operator: "contains", Value: "admin"},
+				{ClaimPath: "custom.tenant_id", Operator: "prefix", Value: "tenant-"},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "rule fails with custom message",
+			rules: []Rule{
+				{ClaimPath: "roles", Operator: "contains", Value: "admin"},
+				{ClaimPath: "custom.tenant_id", Operator: "equals", Value: "tenant-999", Message: "tenant not permitted"},
+			},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "tenant not permitted",
+		},
+		{
+			name:        "rule on missing claim uses default message",
+			rules:       []Rule{{ClaimPath: "tenant.status", Operator: "equals", Value: "active"}},
+			wantStatus:  http.StatusForbidden,
+			wantMessage: "access denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.Rules = tt.rules
+
+			nextCalled := false
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+validTestToken)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if nextCalled != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next called = %v, want %v", nextCalled, tt.wantStatus == http.StatusOK)
+			}
+			if tt.wantMessage != "" {
+				var body map[string]string
+				_ = json.NewDecoder(rr.Body).Decode(&body)
+				if body["error"] != "forbidden" || body["message"] != tt.wantMessage {
+					t.Errorf("body = %v, want error=forbidden message=%q", body, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+// TestServeHTTP_Policies verifies method/path scoped policies authorize requests
+func TestServeHTTP_Policies(t *testing.T) {
+	// validTestToken carries roles ["admin","user"] and no scope claim
+	policies := []Policy{
+		{
+			Methods: []string{"DELETE"},
+			Path:    "/admin/*",
+			Rules:   []Rule{{ClaimPath: "roles", Operator: "contains", Value: "admin"}},
+		},
+		{
+			Methods: []string{"GET"},
+			Path:    "/reports",
+			Rules:   []Rule{{ClaimPath: "scope", Operator: "contains", Value: "reports:read"}},
+			Message: "reports:read scope required",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{"admin delete allowed", "DELETE", "/admin/users", http.StatusOK, ""},
+		{"reports denied", "GET", "/reports", http.StatusForbidden, "reports:read scope required"},
+		{"reports other method unaffected", "POST", "/reports", http.StatusOK, ""},
+		{"unmatched path", "GET", "/profile", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.Policies = policies
+
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+validTestToken)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantMessage != "" {
+				var body map[string]string
+				_ = json.NewDecoder(rr.Body).Decode(&body)
+				if body["message"] != tt.wantMessage {
+					t.Errorf("message = %q, want %q", body["message"], tt.wantMessage)
+				}
+			}
+		})
+	}
+}
+
+// TestServeHTTP_Scopes verifies scope injection and insufficient_scope responses
+func TestServeHTTP_Scopes(t *testing.T) {
+	// {"alg":"HS256"} . {"sub":"user-1","scope":"openid reports:read","scp":["billing"]}
+	token := "eyJhbGciOiJIUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","scope":"openid reports:read","scp":["billing"]}`)) +
+		".signature"
+
+	tests := []struct {
+		name        string
+		requireAll  []string
+		requireAny  []string
+		arrayFormat string
+		wantStatus  int
+		wantHeader  string
+	}{
+		{"no requirements", nil, nil, "comma", http.StatusOK, "openid, reports:read"},
+		{"json format", nil, nil, "json", http.StatusOK, `["openid","reports:read"]`},
+		{"all granted across claims", []string{"reports:read", "billing"}, nil, "comma", http.StatusOK, "openid, reports:read"},
+		{"any granted", nil, []string{"admin", "billing"}, "comma", http.StatusOK, "openid, reports:read"},
+		{"all missing", []string{"reports:write"}, nil, "comma", http.StatusForbidden, ""},
+		{"any missing", nil, []string{"admin"}, "comma", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "scope", HeaderName: "X-Scopes", Scopes: true, ArrayFormat: tt.arrayFormat}}
+			config.RequiredScopes = tt.requireAll
+			config.RequiredAnyScopes = tt.requireAny
+
+			var gotHeader string
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get("X-Scopes")
+				w.WriteHeader(http.StatusOK)
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if gotHeader != tt.wantHeader {
+				t.Errorf("X-Scopes = %q, want %q", gotHeader, tt.wantHeader)
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				if got := rr.Header().Get("WWW-Authenticate"); !contains(got, `error="insufficient_scope"`) {
+					t.Errorf("WWW-Authenticate = %q, want insufficient_scope error", got)
+				}
+				var body map[string]string
+				_ = json.NewDecoder(rr.Body).Decode(&body)
+				if body["error"] != "insufficient_scope" {
+					t.Errorf("error = %q, want insufficient_scope", body["error"])
+				}
+			}
+		})
+	}
+}
+
+// TestServeHTTP_ArraySelectors verifies selected array elements are injected with array formatting
+func TestServeHTTP_ArraySelectors(t *testing.T) {
+	// {"alg":"HS256"} . {"sub":"user-1","orgs":[{"id":"org-1"},{"id":"org-2"}],"groups":["eng","ops"]}
+	token := "eyJhbGciOiJIUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","orgs":[{"id":"org-1"},{"id":"org-2"}],"groups":["eng","ops"]}`)) +
+		".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "orgs[*].id", HeaderName: "X-Org-Ids"},
+		{ClaimPath: "orgs[*].id", HeaderName: "X-Org-Ids-Json", ArrayFormat: "json"},
+		{ClaimPath: "groups[-1]", HeaderName: "X-Last-Group"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-Org-Ids":      "org-1, org-2",
+		"X-Org-Ids-Json": `["org-1","org-2"]`,
+		"X-Last-Group":   "ops",
+	}
+	for header, value := range want {
+		if got.Get(header) != value {
+			t.Errorf("%s = %q, want %q", header, got.Get(header), value)
+		}
+	}
+}
+
+// TestServeHTTP_NamespacedClaims verifies quoted dot paths and JSON Pointers reach URL-style claims
+func TestServeHTTP_NamespacedClaims(t *testing.T) {
+	// {"alg":"HS256"} . {"sub":"user-1","https://example.com/roles":["admin","billing"],"cognito:groups":["ops"]}
+	token := "eyJhbGciOiJIUzI1NiJ9." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user-1","https://example.com/roles":["admin","billing"],"cognito:groups":["ops"]}`)) +
+		".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: `["https://example.com/roles"]`, HeaderName: "X-Roles"},
+		{ClaimPath: "/https:~1~1example.com~1roles/0", PathSyntax: "pointer", HeaderName: "X-First-Role"},
+		{ClaimPath: "cognito:groups", HeaderName: "X-Groups"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-Roles":      "admin, billing",
+		"X-First-Role": "admin",
+		"X-Groups":     "ops",
+	}
+	for header, value := range want {
+		if got.Get(header) != value {
+			t.Errorf("%s = %q, want %q", header, got.Get(header), value)
+		}
+	}
+}
+
+// TestServeHTTP_FilterPath verifies filtered claim paths inject only the matching elements
+func TestServeHTTP_FilterPath(t *testing.T) {
+	payload := `{"sub":"user-1","permissions":[` +
+		`{"resource":"billing","actions":["read","refund"]},` +
+		`{"resource":"reports","actions":["export"]}]}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "permissions[?(@.resource=='billing')].actions[*]", HeaderName: "X-Billing-Actions"},
+		{ClaimPath: "permissions[?(@.resource=='admin')].actions", HeaderName: "X-Admin-Actions"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v := got.Get("X-Billing-Actions"); v != "read, refund" {
+		t.Errorf("X-Billing-Actions = %q, want %q", v, "read, refund")
+	}
+	if _, ok := got["X-Admin-Actions"]; ok {
+		t.Errorf("X-Admin-Actions should not be set when the filter matches nothing")
+	}
+}
+
+// TestServeHTTP_Transforms verifies transformed values are injected and failed transforms skip the header
+func TestServeHTTP_Transforms(t *testing.T) {
+	payload := `{"sub":"auth0|ABC123","email":"Jane.Doe@Example.COM","tenant":{"id":"acme"},"tier":"bronze"}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "sub", HeaderName: "X-User-Id", Transforms: []Transform{{Type: "stripPrefix", Value: "auth0|"}}},
+		{ClaimPath: "email", HeaderName: "X-User-Email", Transforms: []Transform{{Type: "lower"}}},
+		{ClaimPath: "email", HeaderName: "X-Email-Domain", Transforms: []Transform{{Type: "regex", Value: "@(.+)$"}, {Type: "lower"}}},
+		{ClaimPath: "tenant", HeaderName: "X-Tenant", Transforms: []Transform{{Type: "base64"}}},
+		{ClaimPath: "tier", HeaderName: "X-Tier", Transforms: []Transform{{Type: "lookup", Table: map[string]string{"gold": "premium"}}}},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-User-Id":      "ABC123",
+		"X-User-Email":   "jane.doe@example.com",
+		"X-Email-Domain": "example.com",
+		"X-Tenant":       "eyJpZCI6ImFjbWUifQ==",
+	}
+	for header, value := range want {
+		if got.Get(header) != value {
+			t.Errorf("%s = %q, want %q", header, got.Get(header), value)
+		}
+	}
+	if _, ok := got["X-Tier"]; ok {
+		t.Errorf("X-Tier should not be set when the lookup has no entry")
+	}
+}
+
+// TestServeHTTP_Template verifies composite header values and sanitization of rendered output
+func TestServeHTTP_Template(t *testing.T) {
+	payload := `{"sub":"42","tenant":"acme","roles":["admin","user"],"evil":"a\r\nX-Injected: 1"}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{Template: `tenant={{.payload.tenant}};user={{.payload.sub}};role={{.payload.roles | join ","}}`, HeaderName: "X-Identity"},
+		{Template: "{{.payload.evil}}", HeaderName: "X-Evil"},
+		{Template: `{{.payload | json}}{{.payload | json}}`, HeaderName: "X-Too-Large"},
+	}
+	config.MaxHeaderSize = 64
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v := got.Get("X-Identity"); v != "tenant=acme;user=42;role=admin,user" {
+		t.Errorf("X-Identity = %q, want %q", v, "tenant=acme;user=42;role=admin,user")
+	}
+	if v := got.Get("X-Evil"); v != "aX-Injected: 1" {
+		t.Errorf("X-Evil = %q, want control characters removed", v)
+	}
+	if got.Get("X-Injected") != "" {
+		t.Error("template output must not inject additional headers")
+	}
+	if _, ok := got["X-Too-Large"]; ok {
+		t.Error("X-Too-Large should not be set when the rendered value exceeds maxHeaderSize")
+	}
+}
+
+// TestServeHTTP_FallbackPathsAndDefault verifies fallback order and default values
+func TestServeHTTP_FallbackPathsAndDefault(t *testing.T) {
+	mapping := ClaimMapping{
+		ClaimPath:     "email",
+		FallbackPaths: []string{"preferred_username", "upn"},
+		HeaderName:    "X-User",
+		Transforms:    []Transform{{Type: "lower"}},
+	}
+	tenant := ClaimMapping{ClaimPath: "tenant", HeaderName: "X-Tenant-Id", Default: "PUBLIC", Transforms: []Transform{{Type: "lower"}}}
+
+	tests := []struct {
+		name       string
+		payload    string
+		wantUser   string
+		wantTenant string
+	}{
+		{"primary path", `{"email":"Jane@Example.com","preferred_username":"jane","tenant":"Acme"}`, "jane@example.com", "acme"},
+		{"first fallback", `{"preferred_username":"Jane","upn":"jane@corp"}`, "jane", "PUBLIC"},
+		{"second fallback", `{"upn":"Jane@Corp"}`, "jane@corp", "PUBLIC"},
+		{"nothing found", `{"sub":"42"}`, "", "PUBLIC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(tt.payload)) + ".signature"
+
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{mapping, tenant}
+
+			var got http.Header
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+			}), config, "test-plugin")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+			if v := got.Get("X-User"); v != tt.wantUser {
+				t.Errorf("X-User = %q, want %q", v, tt.wantUser)
+			}
+			if v := got.Get("X-Tenant-Id"); v != tt.wantTenant {
+				t.Errorf("X-Tenant-Id = %q, want %q (defaults are not transformed)", v, tt.wantTenant)
+			}
+		})
+	}
+}
+
+// TestServeHTTP_RequiredWithFallback verifies a fallback path satisfies a required mapping
+func TestServeHTTP_RequiredWithFallback(t *testing.T) {
+	config := CreateConfig()
+	config.ContinueOnError = false
+	config.Claims = []ClaimMapping{{ClaimPath: "upn", FallbackPaths: []string{"email"}, HeaderName: "X-User", Required: true}}
+
+	// validTestToken has email but no upn
+	status, _ := serveWithConfig(t, config, validTestToken)
+	if status != http.StatusOK {
+		t.Errorf("Status code = %d, want %d", status, http.StatusOK)
+	}
+
+	config.Claims[0].FallbackPaths = []string{"preferred_username"}
+	status, _ = serveWithConfig(t, config, validTestToken)
+	if status != http.StatusUnauthorized {
+		t.Errorf("Status code = %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+// TestServeHTTP_ArrayFormatMulti verifies one header line per element and per-element transforms
+func TestServeHTTP_ArrayFormatMulti(t *testing.T) {
+	payload := `{"sub":"42","roles":["Admin","Billing, EU"],"groups":["eng","ops"]}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "roles", HeaderName: "X-Role", ArrayFormat: "multi", Transforms: []Transform{{Type: "lower"}}},
+		{ClaimPath: "roles", HeaderName: "X-Roles-SF", ArrayFormat: "sf-list"},
+		{ClaimPath: "groups", HeaderName: "X-Groups", ArrayFormat: "separator", ArraySeparator: "|"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	roles := got.Values("X-Role")
+	if len(roles) != 2 || roles[0] != "admin" || roles[1] != "billing, eu" {
+		t.Errorf("X-Role values = %q, want [admin, billing, eu] as two lines", roles)
+	}
+	if v := got.Get("X-Roles-SF"); v != `"Admin", "Billing, EU"` {
+		t.Errorf("X-Roles-SF = %q, want %q", v, `"Admin", "Billing, EU"`)
+	}
+	if v := got.Get("X-Groups"); v != "eng|ops" {
+		t.Errorf("X-Groups = %q, want %q", v, "eng|ops")
+	}
+}
+
+// TestServeHTTP_NumericClaims verifies exact 64-bit IDs and numeric formatting end to end
+func TestServeHTTP_NumericClaims(t *testing.T) {
+	payload := `{"sub":"42","user_id":9007199254740993,"exp":4102444800,"score":0.875,"ratio":1.5,"ids":[9007199254740993,2],"sf":[123456789012345,2.5]}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "user_id", HeaderName: "X-User-Id"},
+		{ClaimPath: "exp", HeaderName: "X-Expires", NumberFormat: "rfc3339"},
+		{ClaimPath: "score", HeaderName: "X-Score", NumberFormat: "fixed", Decimals: 2},
+		{ClaimPath: "ratio", HeaderName: "X-Ratio", NumberFormat: "integer"},
+		{ClaimPath: "ids", HeaderName: "X-Ids", NumberFormat: "integer"},
+		{ClaimPath: "sf", HeaderName: "X-SF", ArrayFormat: "sf-list"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-User-Id": "9007199254740993",
+		"X-Expires": "2100-01-01T00:00:00Z",
+		"X-Score":   "0.88",
+		"X-Ratio":   "",
+		"X-Ids":     "9007199254740993, 2",
+		"X-SF":      "123456789012345, 2.5",
+	}
+	for name, value := range want {
+		if v := got.Get(name); v != value {
+			t.Errorf("%s = %q, want %q", name, v, value)
+		}
+	}
+}
+
+// TestServeHTTP_Encoding verifies non-ASCII claims are encoded per mapping
+func TestServeHTTP_Encoding(t *testing.T) {
+	payload := `{"name":"José Müller","evil":"admin\u202e\u2028user"}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{
+		{ClaimPath: "name", HeaderName: "X-Name-Raw"},
+		{ClaimPath: "name", HeaderName: "X-Name-Percent", Encoding: "percent"},
+		{ClaimPath: "name", HeaderName: "X-Name-Base64", Encoding: "base64"},
+		{ClaimPath: "name", HeaderName: "X-Name-Ext", Encoding: "rfc8187"},
+		{ClaimPath: "name", HeaderName: "X-Name-Ascii", Encoding: "ascii-fold"},
+		{ClaimPath: "missing", HeaderName: "X-Default", Default: "Zoë", Encoding: "ascii-fold"},
+		{ClaimPath: "evil", HeaderName: "X-Evil"},
+	}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := map[string]string{
+		"X-Name-Raw":     "José Müller",
+		"X-Name-Percent": "Jos%C3%A9 M%C3%BCller",
+		"X-Name-Base64":  "Sm9zw6kgTcO8bGxlcg==",
+		"X-Name-Ext":     "UTF-8''Jos%C3%A9%20M%C3%BCller",
+		"X-Name-Ascii":   "Jose Muller",
+		"X-Default":      "Zoe",
+		"X-Evil":         "adminuser",
+	}
+	for name, value := range want {
+		if v := got.Get(name); v != value {
+			t.Errorf("%s = %q, want %q", name, v, value)
+		}
+	}
+}
+
+// TestServeHTTP_EncodingMulti verifies each header line is encoded for arrayFormat "multi"
+func TestServeHTTP_EncodingMulti(t *testing.T) {
+	payload := `{"roles":["Zoë","admin"]}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{{ClaimPath: "roles", HeaderName: "X-Role", ArrayFormat: "multi", Encoding: "percent"}}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	values := got.Values("X-Role")
+	if len(values) != 2 || values[0] != "Zo%C3%AB" || values[1] != "admin" {
+		t.Errorf("X-Role = %q, want [\"Zo%%C3%%AB\" \"admin\"]", values)
+	}
+}
+
+// TestServeHTTP_ReplacedProtectedHeaders verifies injection honours a replaced protected header list
+func TestServeHTTP_ReplacedProtectedHeaders(t *testing.T) {
+	config := CreateConfig()
+	config.ProtectedHeaders = []string{"X-Internal-Auth"}
+	config.ReplaceProtectedHeaders = true
+	config.AllowedHeaderPatterns = []string{"X-Forwarded-*"}
+	config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-Forwarded-Prefix", Override: true}}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+validTestToken)
+	req.Header.Set("X-Forwarded-Prefix", "/api")
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	if v := got.Get("X-Forwarded-Prefix"); v != "1234567890" {
+		t.Errorf("X-Forwarded-Prefix = %q, want %q", v, "1234567890")
+	}
+}
+
+// TestServeHTTP_PayloadHeader verifies the full payload is injected and spoofed copies are stripped
+func TestServeHTTP_PayloadHeader(t *testing.T) {
+	payload := `{"sub":"42","email":"jane@example.com","user_id":9007199254740993}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	config := CreateConfig()
+	config.StripIncomingHeaders = true
+	config.PayloadHeader = PayloadHeaderConfig{HeaderName: "X-Jwt-Payload", Claims: []string{"sub", "user_id"}, IncludeHeader: true}
+
+	var got http.Header
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}), config, "test-plugin")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Jwt-Payload", "spoofed")
+	plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+	decoded, err := base64.RawURLEncoding.DecodeString(got.Get("X-Jwt-Payload"))
+	if err != nil {
+		t.Fatalf("X-Jwt-Payload is not base64url: %v", err)
+	}
+	want := `{"header":{"alg":"HS256"},"payload":{"sub":"42","user_id":9007199254740993}}`
+	if string(decoded) != want {
+		t.Errorf("X-Jwt-Payload = %s, want %s", decoded, want)
+	}
+}
+
+// TestServeHTTP_PayloadHeaderTooLarge verifies an oversized payload fails or is skipped per continueOnError
+func TestServeHTTP_PayloadHeaderTooLarge(t *testing.T) {
+	payload := `{"sub":"42","bio":"` + strings.Repeat("a", 200) + `"}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	for _, continueOnError := range []bool{true, false} {
+		config := CreateConfig()
+		config.ContinueOnError = continueOnError
+		config.MaxHeaderSize = 128
+		config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+		config.PayloadHeader = PayloadHeaderConfig{HeaderName: "X-Jwt-Payload"}
+
+		var got http.Header
+		plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Clone()
+		}), config, "test-plugin")
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+
+		req := httptest.NewRequest("GET", "http://example.com", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rw := httptest.NewRecorder()
+		plugin.ServeHTTP(rw, req)
+
+		if continueOnError {
+			if got == nil {
+				t.Fatal("request should be forwarded with continueOnError")
+			}
+			if _, ok := got["X-Jwt-Payload"]; ok {
+				t.Error("X-Jwt-Payload should not be set when it exceeds maxHeaderSize")
+			}
+			if got.Get("X-User-Id") != "42" {
+				t.Errorf("X-User-Id = %q, want %q", got.Get("X-User-Id"), "42")
+			}
+			continue
+		}
+		if got != nil {
+			t.Error("request should not be forwarded without continueOnError")
+		}
+		if rw.Code != http.StatusUnauthorized {
+			t.Errorf("status = %d, want %d", rw.Code, http.StatusUnauthorized)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package traefik_jwt_decoder_plugin
 
 import (
+	"encoding/json"
 	"testing"
 )
 
@@ -143,6 +144,10 @@ func TestParseJWT_InvalidJSON(t *testing.T) {
 			name:  "truncated JSON in payload",
 			token: "eyJhbGciOiJIUzI1NiJ9.eyJzdWI.sig", // Truncated JSON
 		},
+		{
+			name:  "trailing data in payload",
+			token: "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxMjMifSB4.sig", // {"sub":"123"} x
+		},
 	}
 
 	for _, tt := range tests {
@@ -158,6 +163,24 @@ func TestParseJWT_InvalidJSON(t *testing.T) {
 	}
 }
 
+// TestParseJWT_PreservesNumbers verifies numeric claims keep their exact text
+func TestParseJWT_PreservesNumbers(t *testing.T) {
+	// Payload: {"id":9007199254740993,"exp":1.5e9}
+	token := "eyJhbGciOiJIUzI1NiJ9.eyJpZCI6OTAwNzE5OTI1NDc0MDk5MywiZXhwIjoxLjVlOX0.sig"
+
+	jwt, err := ParseJWT(token, false)
+	if err != nil {
+		t.Fatalf("ParseJWT(, false) failed: %v", err)
+	}
+
+	if id, ok := jwt.Payload["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+		t.Errorf("Payload id = %#v, want json.Number 9007199254740993", jwt.Payload["id"])
+	}
+	if exp, ok := jwt.Payload["exp"].(json.Number); !ok || exp.String() != "1.5e9" {
+		t.Errorf("Payload exp = %#v, want json.Number 1.5e9", jwt.Payload["exp"])
+	}
+}
+
 // TestParseJWT_EmptyToken verifies error handling for empty token
 func TestParseJWT_EmptyToken(t *testing.T) {
 	jwt, err := ParseJWT("", false)
@@ -0,0 +1,119 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// maxNumberDecimals is the largest ClaimMapping.Decimals accepted for "fixed".
+const maxNumberDecimals = 18
+
+// Epoch seconds representable as an RFC 3339 timestamp (years 0001 to 9999).
+const (
+	minRFC3339Seconds = -62135596800
+	maxRFC3339Seconds = 253402300799
+)
+
+// numberFormats lists the supported ClaimMapping.NumberFormat values.
+var numberFormats = map[string]bool{
+	"integer": true,
+	"fixed":   true,
+	"rfc3339": true,
+}
+
+// FormatClaimNumbers replaces the numbers in a claim value with their
+// formatted string form. Scalars and array elements (including nested arrays)
+// are formatted; strings, booleans and objects are returned unchanged.
+// An empty format returns value as-is, so numbers keep their exact text.
+// Numbers whose "integer" or "fixed" text would exceed maxSize bytes are
+// rejected before they are written out, so a tiny claim such as 1e5000000
+// cannot make the plugin produce millions of digits.
+//
+// Formats:
+//   - "integer": the number without a fractional part, e.g. 1e3 → "1000";
+//     a number with a fraction is an error
+//   - "fixed": the number rounded to decimals fractional digits, e.g. 2.5 → "2.50"
+//   - "rfc3339": epoch seconds as a UTC timestamp, e.g. 1700000000 → "2023-11-14T22:13:20Z";
+//     fractional seconds are truncated
+//
+// Example:
+//   value, _ := FormatClaimNumbers(json.Number("1700000000"), "rfc3339", 0, 8192)
+//   // Returns: "2023-11-14T22:13:20Z"
+//
+// Returns an error if a number cannot be represented in the format.
+func FormatClaimNumbers(value interface{}, format string, decimals, maxSize int) (interface{}, error) {
+	if format == "" {
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		return formatNumber(v, format, decimals, maxSize)
+	case float64:
+		return formatNumber(json.Number(strconv.FormatFloat(v, 'g', -1, 64)), format, decimals, maxSize)
+	case int:
+		return formatNumber(json.Number(strconv.Itoa(v)), format, decimals, maxSize)
+	case []interface{}:
+		formatted := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			f, err := FormatClaimNumbers(elem, format, decimals, maxSize)
+			if err != nil {
+				return nil, err
+			}
+			formatted = append(formatted, f)
+		}
+		return formatted, nil
+	default:
+		return value, nil
+	}
+}
+
+// formatNumber formats a single number. Parsing the exact decimal text with
+// big.Float keeps integers beyond float64 precision intact.
+func formatNumber(n json.Number, format string, decimals, maxSize int) (string, error) {
+	f, _, err := big.ParseFloat(n.String(), 10, 256, big.ToNearestEven)
+	if err != nil {
+		return "", fmt.Errorf("invalid number '%s'", n)
+	}
+
+	// Bound the output before writing it: a binary exponent of exp gives at
+	// most exp*log10(2)+1 integer digits, plus sign, point and decimals.
+	// Numbers below 2^-64 round to zero at any supported precision and are
+	// replaced by a signed zero, so their digits are never expanded either.
+	if format == "integer" || format == "fixed" {
+		exp := f.MantExp(nil)
+		if exp > 0 && exp*30103/100000+decimals+3 > maxSize {
+			return "", fmt.Errorf("number %s exceeds maximum header size (%d bytes)", n, maxSize)
+		}
+		if exp < -64 && format == "fixed" {
+			zero := new(big.Float)
+			if f.Signbit() {
+				zero.Neg(zero)
+			}
+			f = zero
+		}
+	}
+
+	switch format {
+	case "integer":
+		if !f.IsInt() {
+			return "", fmt.Errorf("number %s is not an integer", n)
+		}
+		return f.Text('f', 0), nil
+
+	case "fixed":
+		return f.Text('f', decimals), nil
+
+	case "rfc3339":
+		seconds, _ := f.Int64()
+		if f.IsInf() || seconds < minRFC3339Seconds || seconds > maxRFC3339Seconds {
+			return "", fmt.Errorf("number %s is out of range for an RFC 3339 timestamp", n)
+		}
+		return time.Unix(seconds, 0).UTC().Format(time.RFC3339), nil
+	}
+
+	return "", fmt.Errorf("unknown number format '%s'", format)
+}
@@ -0,0 +1,66 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestFormatClaimNumbers verifies integer, fixed and RFC 3339 number formatting
+func TestFormatClaimNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		format   string
+		decimals int
+		want     interface{}
+		wantErr  bool
+	}{
+		{"no format keeps value", json.Number("1.50"), "", 0, json.Number("1.50"), false},
+		{"integer beyond float64 precision", json.Number("9007199254740993"), "integer", 0, "9007199254740993", false},
+		{"integer from exponent", json.Number("1e3"), "integer", 0, "1000", false},
+		{"integer from float64", float64(42), "integer", 0, "42", false},
+		{"integer rejects fraction", json.Number("1.5"), "integer", 0, nil, true},
+		{"fixed pads", json.Number("2.5"), "fixed", 2, "2.50", false},
+		{"fixed rounds", json.Number("3.14159"), "fixed", 3, "3.142", false},
+		{"fixed zero decimals", json.Number("7.8"), "fixed", 0, "8", false},
+		{"rfc3339", json.Number("1700000000"), "rfc3339", 0, "2023-11-14T22:13:20Z", false},
+		{"rfc3339 truncates fraction", json.Number("1700000000.9"), "rfc3339", 0, "2023-11-14T22:13:20Z", false},
+		{"rfc3339 from int", 0, "rfc3339", 0, "1970-01-01T00:00:00Z", false},
+		{"rfc3339 out of range", json.Number("1e20"), "rfc3339", 0, nil, true},
+		{"array elements", []interface{}{json.Number("1"), "a", []interface{}{json.Number("2.25")}}, "fixed", 1,
+			[]interface{}{"1.0", "a", []interface{}{"2.2"}}, false},
+		{"string unchanged", "123", "integer", 0, "123", false},
+		{"object unchanged", map[string]interface{}{"n": json.Number("1.5")}, "integer", 0,
+			map[string]interface{}{"n": json.Number("1.5")}, false},
+		{"integer exceeds max size", json.Number("1e9000"), "integer", 0, nil, true},
+		{"fixed exceeds max size", json.Number("1e9000"), "fixed", 2, nil, true},
+		{"error in array", []interface{}{json.Number("1"), json.Number("1.5")}, "integer", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatClaimNumbers(tt.value, tt.format, tt.decimals, 8192)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatClaimNumbers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FormatClaimNumbers() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatClaimNumbers_MaxSize verifies the size bound applies to the formatted text
+func TestFormatClaimNumbers_MaxSize(t *testing.T) {
+	if got, err := FormatClaimNumbers(json.Number("1e40"), "integer", 0, 50); err != nil || got != "1"+strings.Repeat("0", 40) {
+		t.Errorf("FormatClaimNumbers() = %v, %v; want 1e40 written out", got, err)
+	}
+	if _, err := FormatClaimNumbers(json.Number("1e40"), "integer", 0, 20); err == nil {
+		t.Error("FormatClaimNumbers() expected error for 41 digits with maxSize 20, got nil")
+	}
+	if got, err := FormatClaimNumbers(json.Number("-1e-5000000"), "fixed", 2, 20); err != nil || got != "-0.00" {
+		t.Errorf("FormatClaimNumbers() = %v, %v; want \"-0.00\" for a tiny number", got, err)
+	}
+}
@@ -0,0 +1,63 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// payloadHeaderFormats lists the supported PayloadHeaderConfig.Format values.
+var payloadHeaderFormats = map[string]bool{
+	"base64": true,
+	"json":   true,
+}
+
+// EncodePayloadHeader serializes a token's claims into a single header value.
+//
+// The payload is filtered to cfg.Claims when set (top-level names; names the
+// token lacks are omitted). With cfg.IncludeHeader the document becomes
+// {"header": <JWT header>, "payload": <payload>}. Numbers keep their exact
+// text and object keys are sorted, so equal tokens produce equal values.
+//
+// Formats:
+//   - "" or "base64": base64url-encoded JSON without padding
+//   - "json": the JSON document as-is
+//
+// Example:
+//   value, _ := EncodePayloadHeader(jwt, PayloadHeaderConfig{Claims: []string{"sub"}}, 8192)
+//   // Returns: "eyJzdWIiOiI0MiJ9" (base64url of {"sub":"42"})
+//
+// Returns an error if the value would exceed maxSize bytes.
+func EncodePayloadHeader(jwt *JWT, cfg PayloadHeaderConfig, maxSize int) (string, error) {
+	payload := jwt.Payload
+	if len(cfg.Claims) > 0 {
+		payload = make(map[string]interface{}, len(cfg.Claims))
+		for _, name := range cfg.Claims {
+			if value, ok := jwt.Payload[name]; ok {
+				payload[name] = value
+			}
+		}
+	}
+
+	var document interface{} = payload
+	if cfg.IncludeHeader {
+		document = map[string]interface{}{
+			"header":  jwt.Header,
+			"payload": payload,
+		}
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload header: %w", err)
+	}
+
+	value := string(data)
+	if cfg.Format != "json" {
+		value = base64.RawURLEncoding.EncodeToString(data)
+	}
+	if len(value) > maxSize {
+		return "", fmt.Errorf("payload header is %d bytes, exceeds maxHeaderSize (%d bytes)", len(value), maxSize)
+	}
+	return value, nil
+}
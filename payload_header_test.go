@@ -0,0 +1,55 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+// TestEncodePayloadHeader verifies payload filtering, header inclusion and formats
+func TestEncodePayloadHeader(t *testing.T) {
+	jwt := &JWT{
+		Header: map[string]interface{}{"alg": "RS256", "kid": "k1"},
+		Payload: map[string]interface{}{
+			"sub":     "42",
+			"user_id": json.Number("9007199254740993"),
+			"roles":   []interface{}{"admin"},
+		},
+	}
+
+	tests := []struct {
+		name string
+		cfg  PayloadHeaderConfig
+		want string
+	}{
+		{"full payload as json", PayloadHeaderConfig{Format: "json"}, `{"roles":["admin"],"sub":"42","user_id":9007199254740993}`},
+		{"filtered payload", PayloadHeaderConfig{Format: "json", Claims: []string{"sub", "email"}}, `{"sub":"42"}`},
+		{"with JWT header", PayloadHeaderConfig{Format: "json", Claims: []string{"sub"}, IncludeHeader: true}, `{"header":{"alg":"RS256","kid":"k1"},"payload":{"sub":"42"}}`},
+		{"base64url by default", PayloadHeaderConfig{Claims: []string{"sub"}}, base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42"}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodePayloadHeader(jwt, tt.cfg, 8192)
+			if err != nil {
+				t.Fatalf("EncodePayloadHeader() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("EncodePayloadHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestEncodePayloadHeader_TooLarge verifies the maxHeaderSize limit applies to the encoded value
+func TestEncodePayloadHeader_TooLarge(t *testing.T) {
+	jwt := &JWT{Payload: map[string]interface{}{"sub": "42"}}
+
+	// {"sub":"42"} is 12 bytes as JSON and 16 bytes as base64url
+	if _, err := EncodePayloadHeader(jwt, PayloadHeaderConfig{Format: "json"}, 12); err != nil {
+		t.Errorf("EncodePayloadHeader() unexpected error at the limit: %v", err)
+	}
+	if _, err := EncodePayloadHeader(jwt, PayloadHeaderConfig{}, 12); err == nil {
+		t.Error("EncodePayloadHeader() expected error for oversized value, got nil")
+	}
+}
@@ -0,0 +1,173 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// compiledPolicy is a Policy with its method set and rules prepared for
+// evaluation. It is immutable after compilation and safe for concurrent use.
+type compiledPolicy struct {
+	policy  Policy
+	methods map[string]bool
+	rules   []*compiledRule
+
+	// subtree is the glob before a trailing "/*" or "/**" in Path; such a
+	// policy covers that path and everything under it
+	subtree  string
+	segments int
+	isTree   bool
+}
+
+// compilePolicy validates a Policy and prepares it for evaluation.
+//
+// Returns an error if:
+//   - path is not a valid glob pattern
+//   - both path and pathPrefix are set
+//   - a method is empty
+//   - the policy has no rules, or any rule is invalid
+//
+// Every problem is reported in one joined error.
+func compilePolicy(policy Policy) (*compiledPolicy, error) {
+	var errs []error
+
+	if policy.Path != "" && policy.PathPrefix != "" {
+		errs = append(errs, fmt.Errorf("path and pathPrefix are mutually exclusive"))
+	}
+	if policy.Path != "" {
+		if _, err := path.Match(policy.Path, "/"); err != nil {
+			errs = append(errs, fmt.Errorf("invalid path pattern '%s': %v", policy.Path, err))
+		}
+	}
+
+	cp := &compiledPolicy{policy: policy}
+	if base, ok := subtreeBase(policy.Path); ok {
+		cp.subtree = base
+		cp.segments = strings.Count(base, "/")
+		cp.isTree = true
+	}
+
+	if len(policy.Methods) > 0 {
+		cp.methods = make(map[string]bool, len(policy.Methods))
+		for _, method := range policy.Methods {
+			if method == "" {
+				errs = append(errs, fmt.Errorf("methods cannot contain an empty value"))
+				break
+			}
+			cp.methods[strings.ToUpper(method)] = true
+		}
+	}
+
+	if len(policy.Rules) == 0 {
+		errs = append(errs, fmt.Errorf("at least one rule is required"))
+	}
+	rules, err := compileRules(policy.Rules)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cp.rules = rules
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cp, nil
+}
+
+// compilePolicies compiles a list of policies. Every problem is reported,
+// prefixed with its policy index, in one joined error.
+func compilePolicies(policies []Policy) ([]*compiledPolicy, error) {
+	compiled := make([]*compiledPolicy, 0, len(policies))
+	var errs []error
+	for i, policy := range policies {
+		cp, err := compilePolicy(policy)
+		if err != nil {
+			errs = append(errs, prefixErrors(fmt.Sprintf("policy %d", i), err)...)
+			continue
+		}
+		compiled = append(compiled, cp)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return compiled, nil
+}
+
+// subtreeBase returns the glob before a trailing "/*" or "/**" in pattern.
+func subtreeBase(pattern string) (string, bool) {
+	for _, suffix := range []string{"/**", "/*"} {
+		if strings.HasSuffix(pattern, suffix) {
+			return strings.TrimSuffix(pattern, suffix), true
+		}
+	}
+	return "", false
+}
+
+// matches reports whether the policy applies to the request's method and path.
+// A policy with no methods matches every method, and a policy with neither
+// path nor pathPrefix matches every path.
+//
+// The request path is cleaned first, so "/admin/../admin/x" and "//admin/x"
+// match "/admin/*". A path ending in "/*" or "/**" covers the whole subtree:
+// "/admin/*" matches "/admin", "/admin/x" and "/admin/x/y". A pathPrefix
+// matches whole segments only: "/reports" matches "/reports" and
+// "/reports/q3" but not "/reportsX".
+func (p *compiledPolicy) matches(req *http.Request) bool {
+	if p.methods != nil && !p.methods[req.Method] {
+		return false
+	}
+
+	reqPath := path.Clean("/" + req.URL.Path)
+	switch {
+	case p.isTree:
+		if p.segments == 0 {
+			return true
+		}
+		head, ok := leadingSegments(reqPath, p.segments)
+		if !ok {
+			return false
+		}
+		matched, _ := path.Match(p.subtree, head)
+		return matched
+	case p.policy.Path != "":
+		matched, _ := path.Match(p.policy.Path, reqPath)
+		return matched
+	case p.policy.PathPrefix != "":
+		prefix := strings.TrimSuffix(p.policy.PathPrefix, "/")
+		return reqPath == prefix || strings.HasPrefix(reqPath, prefix+"/")
+	default:
+		return true
+	}
+}
+
+// leadingSegments returns the first n segments of a cleaned absolute path,
+// e.g. leadingSegments("/a/b/c", 2) is "/a/b". It reports false when the
+// path has fewer than n segments.
+func leadingSegments(p string, n int) (string, bool) {
+	end := 0
+	for i := 0; i < n; i++ {
+		next := strings.IndexByte(p[end+1:], '/')
+		if next < 0 {
+			if i == n-1 && len(p) > 1 {
+				return p, true
+			}
+			return "", false
+		}
+		end += next + 1
+	}
+	return p[:end], true
+}
+
+// message returns the failure message for a rule of this policy: the rule's
+// own message, then the policy message, then the default.
+func (p *compiledPolicy) message(rule *compiledRule) string {
+	if rule.rule.Message != "" {
+		return rule.rule.Message
+	}
+	if p.policy.Message != "" {
+		return p.policy.Message
+	}
+	return defaultRuleMessage
+}
@@ -0,0 +1,83 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+// TestCompilePolicy_Invalid verifies policy validation errors
+func TestCompilePolicy_Invalid(t *testing.T) {
+	adminRule := []Rule{{ClaimPath: "roles", Operator: "contains", Value: "admin"}}
+
+	tests := []struct {
+		name   string
+		policy Policy
+	}{
+		{"no rules", Policy{PathPrefix: "/admin"}},
+		{"invalid rule", Policy{PathPrefix: "/admin", Rules: []Rule{{ClaimPath: "roles", Operator: "has"}}}},
+		{"bad glob", Policy{Path: "/admin/[", Rules: adminRule}},
+		{"path and pathPrefix", Policy{Path: "/admin/*", PathPrefix: "/admin", Rules: adminRule}},
+		{"empty method", Policy{Methods: []string{"GET", ""}, Rules: adminRule}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compilePolicy(tt.policy); err == nil {
+				t.Error("compilePolicy() expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestCompiledPolicy_Matches verifies method and path matching
+func TestCompiledPolicy_Matches(t *testing.T) {
+	adminRule := []Rule{{ClaimPath: "roles", Operator: "contains", Value: "admin"}}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		method string
+		path   string
+		want   bool
+	}{
+		{"glob match", Policy{Methods: []string{"DELETE"}, Path: "/admin/*"}, "DELETE", "/admin/users", true},
+		{"subtree glob covers nested paths", Policy{Path: "/admin/*"}, "GET", "/admin/users/42", true},
+		{"subtree glob covers bare prefix", Policy{Path: "/admin/*"}, "DELETE", "/admin", true},
+		{"subtree glob covers trailing slash", Policy{Path: "/admin/*"}, "DELETE", "/admin/", true},
+		{"subtree glob respects segment boundary", Policy{Path: "/admin/*"}, "DELETE", "/administrator", false},
+		{"subtree glob on parent", Policy{Path: "/admin/*"}, "DELETE", "/", false},
+		{"double-star subtree glob", Policy{Path: "/admin/**"}, "DELETE", "/admin/x/y", true},
+		{"subtree glob with inner wildcard", Policy{Path: "/tenants/*/admin/*"}, "GET", "/tenants/acme/admin/users/42", true},
+		{"subtree glob with inner wildcard mismatch", Policy{Path: "/tenants/*/admin/*"}, "GET", "/tenants/acme/billing/x", false},
+		{"subtree glob with inner wildcard bare", Policy{Path: "/tenants/*/admin/*"}, "GET", "/tenants/acme/admin", true},
+		{"root subtree glob matches all", Policy{Path: "/*"}, "GET", "/a/b/c", true},
+		{"inner glob stays within a segment", Policy{Path: "/files/*.pdf"}, "GET", "/files/a/b.pdf", false},
+		{"inner glob match", Policy{Path: "/files/*.pdf"}, "GET", "/files/b.pdf", true},
+		{"glob method mismatch", Policy{Methods: []string{"DELETE"}, Path: "/admin/*"}, "GET", "/admin/users", false},
+		{"lowercase method config", Policy{Methods: []string{"delete"}, Path: "/admin/*"}, "DELETE", "/admin/users", true},
+		{"prefix match", Policy{PathPrefix: "/reports"}, "GET", "/reports/2026/q3", true},
+		{"prefix mismatch", Policy{PathPrefix: "/reports"}, "GET", "/billing", false},
+		{"prefix exact path", Policy{PathPrefix: "/reports"}, "GET", "/reports", true},
+		{"prefix respects segment boundary", Policy{PathPrefix: "/reports"}, "GET", "/reportsX", false},
+		{"prefix with trailing slash", Policy{PathPrefix: "/reports/"}, "GET", "/reports/q3", true},
+		{"root prefix matches all", Policy{PathPrefix: "/"}, "GET", "/anything", true},
+		{"glob on dot-dot path", Policy{Path: "/admin/*"}, "DELETE", "/public/../admin/users", true},
+		{"glob on doubled slashes", Policy{Path: "/admin/*"}, "DELETE", "//admin//users", true},
+		{"prefix on dot-dot path", Policy{PathPrefix: "/reports"}, "GET", "/public/../reports/q3", true},
+		{"no path matches all", Policy{Methods: []string{"POST"}}, "POST", "/anything", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.policy.Rules = adminRule
+			cp, err := compilePolicy(tt.policy)
+			if err != nil {
+				t.Fatalf("compilePolicy() failed: %v", err)
+			}
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.path, nil)
+			if got := cp.matches(req); got != tt.want {
+				t.Errorf("matches(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
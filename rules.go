@@ -0,0 +1,238 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultRuleMessage is returned in the 403 response when a rule has no message.
+const defaultRuleMessage = "access denied"
+
+// ruleOperators lists the supported rule operators and whether each takes a
+// numeric operand. The "not" variants negate their positive counterpart.
+var ruleOperators = map[string]bool{
+	"exists":    false,
+	"equals":    false,
+	"notEquals": false,
+	"in":        false,
+	"notIn":     false,
+	"contains":  false,
+	"prefix":    false,
+	"regex":     false,
+	"gt":        true,
+	"gte":       true,
+	"lt":        true,
+	"lte":       true,
+}
+
+// compiledRule is a Rule with its operands parsed and regex precompiled.
+// It is immutable after compilation and safe for concurrent use.
+type compiledRule struct {
+	rule    Rule
+	pattern *regexp.Regexp
+	number  float64
+}
+
+// compileRule validates a Rule and prepares it for evaluation.
+//
+// Returns an error if:
+//   - claimPath or operator is empty or the operator is unknown
+//   - "in"/"notIn" have no values, or other operators (except "exists") have no value
+//   - A regex does not compile or a numeric operand is not a number
+func compileRule(rule Rule) (*compiledRule, error) {
+	if rule.ClaimPath == "" {
+		return nil, fmt.Errorf("claimPath is required")
+	}
+	if _, err := parseClaimPath(rule.ClaimPath); err != nil {
+		return nil, err
+	}
+
+	numeric, ok := ruleOperators[rule.Operator]
+	if !ok {
+		return nil, fmt.Errorf("invalid operator '%s'", rule.Operator)
+	}
+
+	cr := &compiledRule{rule: rule}
+
+	switch {
+	case rule.Operator == "exists":
+		// no operand
+	case rule.Operator == "in" || rule.Operator == "notIn":
+		if len(rule.Values) == 0 {
+			return nil, fmt.Errorf("operator '%s' requires values", rule.Operator)
+		}
+	case numeric:
+		n, err := strconv.ParseFloat(rule.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("operator '%s' requires a numeric value, got '%s'", rule.Operator, rule.Value)
+		}
+		cr.number = n
+	default:
+		if rule.Value == "" {
+			return nil, fmt.Errorf("operator '%s' requires a value", rule.Operator)
+		}
+		if rule.Operator == "regex" {
+			pattern, err := regexp.Compile(rule.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex: %v", err)
+			}
+			cr.pattern = pattern
+		}
+	}
+
+	return cr, nil
+}
+
+// compileRules compiles a list of rules. Every invalid rule is reported,
+// prefixed with its index, in one joined error.
+func compileRules(rules []Rule) ([]*compiledRule, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	var errs []error
+	for i, rule := range rules {
+		cr, err := compileRule(rule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i, err))
+			continue
+		}
+		compiled = append(compiled, cr)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return compiled, nil
+}
+
+// message returns the rule's configured failure message or the default.
+func (r *compiledRule) message() string {
+	if r.rule.Message != "" {
+		return r.rule.Message
+	}
+	return defaultRuleMessage
+}
+
+// evaluate reports whether a claim value satisfies the rule. found is false
+// when the claim does not exist, which fails every operator except the
+// negated ones ("notEquals", "notIn").
+//
+// Array claims:
+//   - "contains" checks for an element equal to the value
+//   - other operators pass when any element satisfies them, and the negated
+//     operators pass only when no element does
+func (r *compiledRule) evaluate(value interface{}, found bool) bool {
+	switch r.rule.Operator {
+	case "exists":
+		return found
+	case "notEquals":
+		return !found || !r.matchAny(value, "equals")
+	case "notIn":
+		return !found || !r.matchAny(value, "in")
+	}
+
+	if !found {
+		return false
+	}
+
+	if r.rule.Operator == "contains" {
+		if arr, ok := value.([]interface{}); ok {
+			for _, elem := range arr {
+				if s, ok := scalarString(elem); ok && s == r.rule.Value {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	return r.matchAny(value, r.rule.Operator)
+}
+
+// matchAny applies operator to a scalar value, or to each element of an array
+// value, returning true on the first match.
+func (r *compiledRule) matchAny(value interface{}, operator string) bool {
+	if arr, ok := value.([]interface{}); ok {
+		for _, elem := range arr {
+			if r.matchScalar(elem, operator) {
+				return true
+			}
+		}
+		return false
+	}
+	return r.matchScalar(value, operator)
+}
+
+// matchScalar applies operator to a single non-array value.
+func (r *compiledRule) matchScalar(value interface{}, operator string) bool {
+	switch operator {
+	case "gt", "gte", "lt", "lte":
+		n, ok := claimNumber(value)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case "gt":
+			return n > r.number
+		case "gte":
+			return n >= r.number
+		case "lt":
+			return n < r.number
+		default:
+			return n <= r.number
+		}
+	}
+
+	s, ok := scalarString(value)
+	if !ok {
+		return false
+	}
+
+	switch operator {
+	case "equals":
+		return s == r.rule.Value
+	case "in":
+		for _, v := range r.rule.Values {
+			if s == v {
+				return true
+			}
+		}
+		return false
+	case "contains":
+		return strings.Contains(s, r.rule.Value)
+	case "prefix":
+		return strings.HasPrefix(s, r.rule.Value)
+	case "regex":
+		return r.pattern.MatchString(s)
+	}
+
+	return false
+}
+
+// scalarString converts a string, number or boolean claim to its string form.
+// Objects, arrays and null are not scalars.
+func scalarString(value interface{}) (string, bool) {
+	switch value.(type) {
+	case string, bool, float64, int, json.Number:
+		s, err := ConvertClaimToString(value, "")
+		return s, err == nil
+	default:
+		return "", false
+	}
+}
+
+// claimNumber converts a numeric claim to float64.
+func claimNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,85 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"testing"
+)
+
+// TestCompileRule_Invalid verifies rule validation errors
+func TestCompileRule_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"missing claimPath", Rule{Operator: "equals", Value: "x"}},
+		{"unknown operator", Rule{ClaimPath: "sub", Operator: "like", Value: "x"}},
+		{"equals without value", Rule{ClaimPath: "sub", Operator: "equals"}},
+		{"in without values", Rule{ClaimPath: "sub", Operator: "in"}},
+		{"invalid regex", Rule{ClaimPath: "sub", Operator: "regex", Value: "([a-z"}},
+		{"non-numeric gt", Rule{ClaimPath: "age", Operator: "gt", Value: "eighteen"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileRule(tt.rule); err == nil {
+				t.Error("compileRule() expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestCompiledRule_Evaluate verifies each operator against scalar, array and missing claims
+func TestCompiledRule_Evaluate(t *testing.T) {
+	roles := []interface{}{"admin", "user"}
+
+	tests := []struct {
+		name  string
+		rule  Rule
+		value interface{}
+		found bool
+		want  bool
+	}{
+		{"exists present", Rule{Operator: "exists"}, "x", true, true},
+		{"exists missing", Rule{Operator: "exists"}, nil, false, false},
+		{"equals match", Rule{Operator: "equals", Value: "active"}, "active", true, true},
+		{"equals mismatch", Rule{Operator: "equals", Value: "active"}, "suspended", true, false},
+		{"equals missing", Rule{Operator: "equals", Value: "active"}, nil, false, false},
+		{"equals number", Rule{Operator: "equals", Value: "42"}, float64(42), true, true},
+		{"equals bool", Rule{Operator: "equals", Value: "true"}, true, true, true},
+		{"equals object", Rule{Operator: "equals", Value: "{}"}, map[string]interface{}{}, true, false},
+		{"notEquals mismatch", Rule{Operator: "notEquals", Value: "suspended"}, "active", true, true},
+		{"notEquals match", Rule{Operator: "notEquals", Value: "suspended"}, "suspended", true, false},
+		{"notEquals missing", Rule{Operator: "notEquals", Value: "suspended"}, nil, false, true},
+		{"in match", Rule{Operator: "in", Values: []string{"eu", "us"}}, "eu", true, true},
+		{"in mismatch", Rule{Operator: "in", Values: []string{"eu", "us"}}, "apac", true, false},
+		{"in array any", Rule{Operator: "in", Values: []string{"owner", "admin"}}, roles, true, true},
+		{"notIn array", Rule{Operator: "notIn", Values: []string{"banned"}}, roles, true, true},
+		{"notIn array match", Rule{Operator: "notIn", Values: []string{"user"}}, roles, true, false},
+		{"contains array", Rule{Operator: "contains", Value: "admin"}, roles, true, true},
+		{"contains array missing", Rule{Operator: "contains", Value: "owner"}, roles, true, false},
+		{"contains array partial", Rule{Operator: "contains", Value: "adm"}, roles, true, false},
+		{"contains string", Rule{Operator: "contains", Value: "@example"}, "a@example.com", true, true},
+		{"prefix match", Rule{Operator: "prefix", Value: "auth0|"}, "auth0|123", true, true},
+		{"prefix mismatch", Rule{Operator: "prefix", Value: "auth0|"}, "google|123", true, false},
+		{"regex match", Rule{Operator: "regex", Value: `^[a-z]+@example\.com$`}, "alice@example.com", true, true},
+		{"regex mismatch", Rule{Operator: "regex", Value: `^[a-z]+@example\.com$`}, "alice@evil.com", true, false},
+		{"gt true", Rule{Operator: "gt", Value: "2"}, float64(3), true, true},
+		{"gt equal", Rule{Operator: "gt", Value: "3"}, float64(3), true, false},
+		{"gte equal", Rule{Operator: "gte", Value: "3"}, float64(3), true, true},
+		{"lt true", Rule{Operator: "lt", Value: "10.5"}, float64(10), true, true},
+		{"lte false", Rule{Operator: "lte", Value: "1"}, float64(2), true, false},
+		{"gt non-numeric claim", Rule{Operator: "gt", Value: "1"}, "5", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.rule.ClaimPath = "claim"
+			cr, err := compileRule(tt.rule)
+			if err != nil {
+				t.Fatalf("compileRule() failed: %v", err)
+			}
+			if got := cr.evaluate(tt.value, tt.found); got != tt.want {
+				t.Errorf("evaluate(%v, %v) = %v, want %v", tt.value, tt.found, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultScopeClaims are the claims searched for OAuth2 scopes when
+// scopeClaims is not configured: "scope" (RFC 8693 space-delimited string)
+// and "scp" (array form used by several identity providers).
+var defaultScopeClaims = []string{"scope", "scp"}
+
+// ParseScopes normalizes an OAuth2 scope claim into a list of scopes.
+// Accepts a space-delimited string ("read write") or an array of strings
+// (["read", "write"]); array elements may themselves be space-delimited.
+// Duplicates and empty entries are dropped and first-seen order is kept.
+//
+// Example:
+//   scopes, _ := ParseScopes("openid profile reports:read")
+//   // Returns: ["openid", "profile", "reports:read"]
+//
+//   scopes, _ := ParseScopes([]interface{}{"openid", "profile"})
+//   // Returns: ["openid", "profile"]
+//
+// Returns an error if the value is neither a string nor an array of strings.
+func ParseScopes(value interface{}) ([]string, error) {
+	var raw []string
+
+	switch v := value.(type) {
+	case string:
+		raw = []string{v}
+	case []interface{}:
+		for _, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, fmt.Errorf("scope array contains a non-string value")
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("scope claim must be a string or array of strings")
+	}
+
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, entry := range raw {
+		for _, scope := range strings.Fields(entry) {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return scopes, nil
+}
+
+// scopesToArray converts scopes into the []interface{} form used for array
+// claims, so they can be formatted by ConvertClaimToString.
+func scopesToArray(scopes []string) []interface{} {
+	arr := make([]interface{}, len(scopes))
+	for i, scope := range scopes {
+		arr[i] = scope
+	}
+	return arr
+}
+
+// CheckScopes reports which required scopes are not granted.
+//
+// Rules:
+//   - requireAll: every listed scope must be granted
+//   - requireAny: at least one listed scope must be granted (ignored when empty)
+//
+// Returns the scopes that caused the check to fail (all missing requireAll
+// scopes, or the whole requireAny list when none is granted), or nil on success.
+func CheckScopes(granted, requireAll, requireAny []string) []string {
+	set := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		set[scope] = true
+	}
+
+	var missing []string
+	for _, scope := range requireAll {
+		if !set[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return missing
+	}
+
+	if len(requireAny) == 0 {
+		return nil
+	}
+	for _, scope := range requireAny {
+		if set[scope] {
+			return nil
+		}
+	}
+	return requireAny
+}
@@ -0,0 +1,68 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseScopes verifies normalization of string and array scope claims
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    []string
+		wantErr bool
+	}{
+		{"space-delimited string", "openid profile reports:read", []string{"openid", "profile", "reports:read"}, false},
+		{"extra whitespace", "  read   write ", []string{"read", "write"}, false},
+		{"empty string", "", nil, false},
+		{"array", []interface{}{"read", "write"}, []string{"read", "write"}, false},
+		{"array with delimited element", []interface{}{"read write", "admin"}, []string{"read", "write", "admin"}, false},
+		{"duplicates removed", "read write read", []string{"read", "write"}, false},
+		{"array with non-string", []interface{}{"read", float64(1)}, nil, true},
+		{"number", float64(42), nil, true},
+		{"object", map[string]interface{}{"read": true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckScopes verifies all-of and any-of scope requirements
+func TestCheckScopes(t *testing.T) {
+	granted := []string{"openid", "reports:read", "reports:write"}
+
+	tests := []struct {
+		name        string
+		requireAll  []string
+		requireAny  []string
+		wantMissing []string
+	}{
+		{"no requirements", nil, nil, nil},
+		{"all granted", []string{"openid", "reports:read"}, nil, nil},
+		{"all partially granted", []string{"openid", "admin"}, nil, []string{"admin"}},
+		{"any granted", nil, []string{"admin", "reports:write"}, nil},
+		{"any not granted", nil, []string{"admin", "billing"}, []string{"admin", "billing"}},
+		{"all and any", []string{"openid"}, []string{"reports:read"}, nil},
+		{"all fails before any", []string{"admin"}, []string{"reports:read"}, []string{"admin"}},
+		{"no substring match", []string{"reports"}, nil, []string{"reports"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckScopes(granted, tt.requireAll, tt.requireAny)
+			if !reflect.DeepEqual(got, tt.wantMissing) {
+				t.Errorf("CheckScopes() = %v, want %v", got, tt.wantMissing)
+			}
+		})
+	}
+}
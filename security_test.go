@@ -2,11 +2,13 @@ package traefik_jwt_decoder_plugin
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestSecurity_UnicodeNormalizationAttack verifies protection against Unicode CRLF injection
@@ -58,6 +60,9 @@ func TestSecurity_UnicodeNormalizationAttack(t *testing.T) {
 			if strings.Contains(result, "\n") {
 				t.Errorf("Result still contains LF: %q", result)
 			}
+			if strings.ContainsAny(result, "\u2028\u2029") {
+				t.Errorf("Result still contains a Unicode line separator: %q", result)
+			}
 
 			// Verify text content is preserved (without control chars)
 			if !strings.Contains(result, tt.contains) {
@@ -102,6 +107,12 @@ func TestSecurity_ProtectedHeaderBypass(t *testing.T) {
 		{"CONTENT-TYPE", "CONTENT-TYPE", true},
 		{"Transfer-Encoding", "Transfer-Encoding", true},
 		{"X-Real-IP", "X-Real-IP", true},
+		{"authorization", "authorization", true},
+		{"Cookie", "Cookie", true},
+		{"FORWARDED", "FORWARDED", true},
+		{"X-Forwarded-Prefix", "X-Forwarded-Prefix", true},
+		{"Keep-Alive", "Keep-Alive", true},
+		{"proxy-authorization", "proxy-authorization", true},
 	}
 
 	for _, tt := range tests {
@@ -437,7 +448,7 @@ func TestSecurity_MixedTypeArrayConversion(t *testing.T) {
 	}
 }
 
-// TestSecurity_ProtectedHeaderIntegration verifies protected headers cannot be injected end-to-end
+// TestSecurity_ProtectedHeaderIntegration verifies mappings targeting protected headers are rejected at startup
 func TestSecurity_ProtectedHeaderIntegration(t *testing.T) {
 	protectedHeaders := []string{
 		"Host",
@@ -445,10 +456,17 @@ func TestSecurity_ProtectedHeaderIntegration(t *testing.T) {
 		"X-Forwarded-Host",
 		"X-Forwarded-Proto",
 		"X-Forwarded-Port",
+		"X-Forwarded-Prefix",
+		"Forwarded",
 		"X-Real-IP",
+		"Authorization",
+		"Cookie",
 		"Content-Length",
 		"Content-Type",
 		"Transfer-Encoding",
+		"Connection",
+		"Upgrade",
+		"x-forwarded-for",
 	}
 
 	for _, headerName := range protectedHeaders {
@@ -465,29 +483,8 @@ func TestSecurity_ProtectedHeaderIntegration(t *testing.T) {
 				MaxHeaderSize:   8192,
 			}
 
-			originalValue := "original-value-should-be-preserved"
-			nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Verify protected header was NOT modified
-				if r.Header.Get(headerName) != originalValue {
-					// Some headers like Host are special - just verify they're not the JWT claim value
-					if r.Header.Get(headerName) == "1234567890" {
-						t.Errorf("Protected header %q was modified to JWT claim value", headerName)
-					}
-				}
-				w.WriteHeader(http.StatusOK)
-			})
-
-			plugin, _ := New(context.Background(), nextHandler, config, "test-plugin")
-
-			req := httptest.NewRequest("GET", "http://example.com", nil)
-			req.Header.Set("Authorization", "Bearer "+validTestToken)
-			req.Header.Set(headerName, originalValue)
-
-			rr := httptest.NewRecorder()
-			plugin.ServeHTTP(rr, req)
-
-			if rr.Code != http.StatusOK {
-				t.Errorf("Status code = %d, want %d", rr.Code, http.StatusOK)
+			if _, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), config, "test-plugin"); err == nil {
+				t.Errorf("New() accepted a mapping to protected header %q", headerName)
 			}
 		})
 	}
@@ -546,3 +543,342 @@ func TestSecurity_RepeatedControlCharacters(t *testing.T) {
 		})
 	}
 }
+
+// serveWithConfig runs token through a plugin built from config and reports
+// the status code and the X-User-Id header seen by the next handler
+func serveWithConfig(t *testing.T, config *Config, token string) (int, string) {
+	t.Helper()
+
+	var userID string
+	plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID = r.Header.Get("X-User-Id")
+		w.WriteHeader(http.StatusOK)
+	}), config, "security-test")
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	plugin.ServeHTTP(rr, req)
+	return rr.Code, userID
+}
+
+// TestSecurity_AlgNone verifies unsecured tokens are rejected whenever verification is enabled
+func TestSecurity_AlgNone(t *testing.T) {
+	payload := "eyJzdWIiOiJhZG1pbiJ9" // {"sub":"admin"}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"alg none, empty signature", "eyJhbGciOiJub25lIn0." + payload + "."},        // {"alg":"none"}
+		{"alg None, empty signature", "eyJhbGciOiJOb25lIn0." + payload + "."},        // {"alg":"None"}
+		{"alg NONE, empty signature", "eyJhbGciOiJOT05FIn0." + payload + "."},        // {"alg":"NONE"}
+		{"alg none, junk signature", "eyJhbGciOiJub25lIn0." + payload + ".c2lnbmVk"}, // {"alg":"none"}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = false
+			config.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: testHMACSecret}}}
+
+			code, userID := serveWithConfig(t, config, tt.token)
+			if code != http.StatusUnauthorized {
+				t.Errorf("Status code = %d, want %d", code, http.StatusUnauthorized)
+			}
+			if userID != "" {
+				t.Errorf("X-User-Id = %q, want no injected header", userID)
+			}
+		})
+	}
+}
+
+// TestSecurity_AlgorithmConfusion verifies an RSA public key cannot be abused as an HMAC secret
+func TestSecurity_AlgorithmConfusion(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	pemKey := publicKeyPEM(t, &key.PublicKey)
+
+	// The attacker knows the public key and uses its PEM text as an HS256 secret
+	forged := signHMAC(t, "HS256", pemKey, `{"alg":"HS256","typ":"JWT"}`, `{"sub":"admin"}`)
+
+	tests := []struct {
+		name string
+		key  KeyConfig
+	}{
+		{"unbound RSA key", KeyConfig{PublicKey: pemKey}},
+		{"RSA key bound to RS256", KeyConfig{PublicKey: pemKey, Algorithm: "RS256"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = false
+			config.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{tt.key}}
+
+			code, userID := serveWithConfig(t, config, forged)
+			if code != http.StatusUnauthorized {
+				t.Errorf("Status code = %d, want %d", code, http.StatusUnauthorized)
+			}
+			if userID != "" {
+				t.Errorf("X-User-Id = %q, want no injected header", userID)
+			}
+		})
+	}
+}
+
+// TestSecurity_KeyAlgorithmBinding verifies a key bound to one algorithm rejects its siblings
+func TestSecurity_KeyAlgorithmBinding(t *testing.T) {
+	config := CreateConfig()
+	config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+	config.ContinueOnError = false
+	config.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: testHMACSecret, Algorithm: "HS512"}}}
+
+	code, _ := serveWithConfig(t, config, signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, testPayload))
+	if code != http.StatusUnauthorized {
+		t.Errorf("HS256 with HS512-bound key: Status code = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	code, userID := serveWithConfig(t, config, signHMAC(t, "HS512", testHMACSecret, `{"alg":"HS512"}`, testPayload))
+	if code != http.StatusOK || userID != "1234567890" {
+		t.Errorf("HS512 with HS512-bound key: Status code = %d, X-User-Id = %q", code, userID)
+	}
+}
+
+// TestSecurity_AllowedAlgorithms verifies the allow-list applies with and without verification
+func TestSecurity_AllowedAlgorithms(t *testing.T) {
+	hs256 := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, testPayload)
+	hs512 := signHMAC(t, "HS512", testHMACSecret, `{"alg":"HS512"}`, testPayload)
+
+	tests := []struct {
+		name       string
+		verify     bool
+		token      string
+		wantStatus int
+	}{
+		{"allowed alg without verification", false, hs512, http.StatusOK},
+		{"disallowed alg without verification", false, hs256, http.StatusUnauthorized},
+		{"allowed alg with verification", true, hs512, http.StatusOK},
+		{"disallowed alg with verification", true, hs256, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			config.ContinueOnError = false
+			config.AllowedAlgorithms = []string{"HS512"}
+			if tt.verify {
+				config.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: testHMACSecret}}}
+			}
+
+			code, _ := serveWithConfig(t, config, tt.token)
+			if code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestSecurity_HostileArrayProjection verifies wildcard and filter paths over huge arrays are cut off
+func TestSecurity_HostileArrayProjection(t *testing.T) {
+	items := make([]string, 5000)
+	for i := range items {
+		items[i] = `{"id":"x","tags":["a","b","c"]}`
+	}
+	payload := `{"items":[` + strings.Join(items, ",") + `]}`
+	token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+	paths := []string{
+		"items[*].id",
+		"items[?(@.id == 'x')].id",
+		"items[?(@.tags[?(@ == 'c')])].id",
+	}
+
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: path, HeaderName: "X-User-Id"}}
+
+			status, userID := serveWithConfig(t, config, token)
+			if status != http.StatusOK {
+				t.Errorf("Status code = %d, want %d", status, http.StatusOK)
+			}
+			if userID != "" {
+				t.Errorf("X-User-Id should not be injected past the selection limit, got %d bytes", len(userID))
+			}
+		})
+	}
+}
+
+// TestSecurity_HugeNumberExponent verifies a tiny numeric claim with a huge
+// exponent is rejected before its digits are written out
+func TestSecurity_HugeNumberExponent(t *testing.T) {
+	tests := []struct {
+		claim  string
+		format string
+	}{
+		{"1e5000000", "integer"},
+		{"1e5000000", "fixed"},
+		{"-1e5000000", "integer"},
+		{"1e-5000000", "fixed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.claim, func(t *testing.T) {
+			payload := `{"sub":"42","n":` + tt.claim + `}`
+			token := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{
+				{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				{ClaimPath: "n", HeaderName: "X-Number", NumberFormat: tt.format},
+			}
+
+			start := time.Now()
+			status, userID := serveWithConfig(t, config, token)
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("request took %v, want the number rejected without formatting it", elapsed)
+			}
+			if status != http.StatusOK || userID != "42" {
+				t.Errorf("status = %d, X-User-Id = %q; want %d and \"42\"", status, userID, http.StatusOK)
+			}
+		})
+	}
+}
+
+// TestSecurity_AuthorizationFailsClosed verifies that with continueOnError a
+// request without a usable token is rejected whenever an authorization check
+// applies to it, instead of bypassing the check
+func TestSecurity_AuthorizationFailsClosed(t *testing.T) {
+	adminRule := []Rule{{ClaimPath: "roles", Operator: "contains", Value: "admin"}}
+	adminPolicy := []Policy{{Methods: []string{"DELETE"}, Path: "/admin/*", Rules: adminRule}}
+
+	tests := []struct {
+		name       string
+		configure  func(*Config)
+		method     string
+		path       string
+		authHeader string
+		wantStatus int
+	}{
+		{"policy, no token", func(c *Config) { c.Policies = adminPolicy }, "DELETE", "/admin/x", "", http.StatusUnauthorized},
+		{"policy, malformed token", func(c *Config) { c.Policies = adminPolicy }, "DELETE", "/admin/x", "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"policy, bare subtree path", func(c *Config) { c.Policies = adminPolicy }, "DELETE", "/admin", "", http.StatusUnauthorized},
+		{"policy, nested subtree path", func(c *Config) { c.Policies = adminPolicy }, "DELETE", "/admin/users/42", "", http.StatusUnauthorized},
+		{"policy, dot-dot path", func(c *Config) { c.Policies = adminPolicy }, "DELETE", "/public/../admin/x", "", http.StatusUnauthorized},
+		{"policy, unmatched request", func(c *Config) { c.Policies = adminPolicy }, "GET", "/admin/x", "", http.StatusOK},
+		{"rules, no token", func(c *Config) { c.Rules = adminRule }, "GET", "/", "", http.StatusUnauthorized},
+		{"required scopes, no token", func(c *Config) { c.RequiredScopes = []string{"openid"} }, "GET", "/", "", http.StatusUnauthorized},
+		{"required any scopes, bad signature", func(c *Config) {
+			c.RequiredAnyScopes = []string{"openid"}
+			c.Verification = VerificationConfig{Enabled: true, Keys: []KeyConfig{{Secret: "a-very-long-shared-secret-for-tests"}}}
+		}, "GET", "/", "Bearer " + validTestToken, http.StatusUnauthorized},
+		{"no authorization checks, no token", func(c *Config) {}, "GET", "/", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.Claims = []ClaimMapping{{ClaimPath: "sub", HeaderName: "X-User-Id"}}
+			tt.configure(config)
+
+			nextCalled := false
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+			}), config, "security-test")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest(tt.method, "http://example.com"+tt.path, nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			rr := httptest.NewRecorder()
+			plugin.ServeHTTP(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("Status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if nextCalled != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next called = %v, want %v", nextCalled, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
+
+// TestSecurity_SpoofedIdentityHeaders verifies client-supplied identity headers never reach next
+// when stripIncomingHeaders is enabled, whatever happens to the token
+func TestSecurity_SpoofedIdentityHeaders(t *testing.T) {
+	tokenWithoutEmail := "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"42"}`)) + ".signature"
+
+	tests := []struct {
+		name       string
+		authHeader string
+		wantUserID string
+	}{
+		{"no token", "", ""},
+		{"malformed token", "Bearer not-a-jwt", ""},
+		{"valid token", "Bearer " + tokenWithoutEmail, "42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CreateConfig()
+			config.StripIncomingHeaders = true
+			config.StripHeaderPrefix = "X-Jwt-"
+			config.Claims = []ClaimMapping{
+				{ClaimPath: "sub", HeaderName: "X-User-Id"},
+				{ClaimPath: "email", HeaderName: "X-User-Email"},
+			}
+			config.ProtectedHeaders = []string{"X-Jwt-Trace"}
+
+			var got http.Header
+			plugin, err := New(context.Background(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Clone()
+			}), config, "security-test")
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "http://example.com", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			req.Header.Set("X-User-Id", "admin")
+			req.Header.Add("X-User-Id", "root")
+			req.Header.Set("X-User-Email", "admin@example.com")
+			req.Header.Set("X-Jwt-Roles", "admin")
+			req.Header["x-jwt-tenant"] = []string{"spoofed"} // non-canonical key
+			req.Header["x-user-id"] = []string{"admin"}      // raw lowercase key
+			req.Header["X_User_Id"] = []string{"admin"}      // underscores folded to '-' by many backends
+			req.Header["X_Jwt_Role"] = []string{"admin"}     // matches stripHeaderPrefix once normalized
+			req.Header.Set("X-Jwt-Trace", "trace-1")
+			req.Header.Set("X-Request-Id", "abc")
+			plugin.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got == nil {
+				t.Fatal("request did not reach next handler")
+			}
+			if v := strings.Join(got.Values("X-User-Id"), ","); v != tt.wantUserID {
+				t.Errorf("X-User-Id = %q, want %q", v, tt.wantUserID)
+			}
+			for _, name := range []string{"X-User-Email", "X-Jwt-Roles", "x-jwt-tenant", "x-user-id", "X_User_Id", "X_Jwt_Role"} {
+				if v, ok := got[name]; ok {
+					t.Errorf("spoofed header %s reached next: %q", name, v)
+				}
+			}
+			if v := got.Get("X-Jwt-Trace"); v != "trace-1" {
+				t.Errorf("protected X-Jwt-Trace = %q, want it preserved", v)
+			}
+			if v := got.Get("X-Request-Id"); v != "abc" {
+				t.Errorf("unrelated X-Request-Id = %q, want it preserved", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Limits from RFC 8941 Section 3.3.
+const (
+	sfMaxInteger        = 999999999999999
+	sfMaxDecimalInteger = 999999999999
+)
+
+// formatStructuredList serializes claim array elements as an RFC 8941
+// structured field List, e.g. ["admin", 2, true] → `"admin", 2, ?1`.
+//
+// Element mapping:
+//   - string: sf-string (printable ASCII only, '"' and '\' escaped)
+//   - integral number: sf-integer; other numbers: sf-decimal (3 fraction digits)
+//   - bool: sf-boolean (?1 / ?0)
+//   - array of scalars: inner list, e.g. ("a" "b")
+//
+// Returns an error for objects, nulls, arrays nested more than one level,
+// non-ASCII strings, or numbers out of the structured field range.
+func formatStructuredList(items []interface{}) (string, error) {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		if inner, ok := item.([]interface{}); ok {
+			members := make([]string, 0, len(inner))
+			for _, member := range inner {
+				s, err := formatStructuredItem(member)
+				if err != nil {
+					return "", err
+				}
+				members = append(members, s)
+			}
+			parts = append(parts, "("+strings.Join(members, " ")+")")
+			continue
+		}
+
+		s, err := formatStructuredItem(item)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// formatStructuredItem serializes a scalar claim value as an RFC 8941 bare item.
+func formatStructuredItem(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		var b strings.Builder
+		b.WriteByte('"')
+		for i := 0; i < len(v); i++ {
+			c := v[i]
+			if c < 0x20 || c > 0x7E {
+				return "", fmt.Errorf("string contains characters not allowed in a structured field")
+			}
+			if c == '"' || c == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		}
+		b.WriteByte('"')
+		return b.String(), nil
+
+	case bool:
+		if v {
+			return "?1", nil
+		}
+		return "?0", nil
+
+	case int:
+		return formatStructuredNumber(float64(v))
+
+	case float64:
+		return formatStructuredNumber(v)
+
+	case json.Number:
+		// Integers keep their exact digits; everything else goes through float64
+		if i, err := v.Int64(); err == nil && i >= -sfMaxInteger && i <= sfMaxInteger {
+			return strconv.FormatInt(i, 10), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return "", fmt.Errorf("number %s exceeds structured field range", v)
+		}
+		return formatStructuredNumber(f)
+
+	default:
+		return "", fmt.Errorf("value of type %T cannot be represented in a structured field", value)
+	}
+}
+
+// formatStructuredNumber serializes v as an sf-integer when integral, and as
+// an sf-decimal rounded to three fractional digits otherwise.
+func formatStructuredNumber(v float64) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", fmt.Errorf("number cannot be represented in a structured field")
+	}
+
+	if v == math.Trunc(v) && math.Abs(v) <= sfMaxInteger {
+		return strconv.FormatFloat(v, 'f', 0, 64), nil
+	}
+
+	rounded := math.RoundToEven(v*1000) / 1000
+	if math.Abs(math.Trunc(rounded)) > sfMaxDecimalInteger {
+		return "", fmt.Errorf("number %v exceeds structured field range", v)
+	}
+	s := strconv.FormatFloat(rounded, 'f', 3, 64)
+	s = strings.TrimRight(s, "0")
+	if strings.HasSuffix(s, ".") {
+		s += "0"
+	}
+	return s, nil
+}
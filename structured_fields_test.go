@@ -0,0 +1,42 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"testing"
+)
+
+// TestFormatStructuredList verifies RFC 8941 list serialization of claim arrays
+func TestFormatStructuredList(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []interface{}
+		want    string
+		wantErr bool
+	}{
+		{"strings", []interface{}{"admin", "user"}, `"admin", "user"`, false},
+		{"string with comma", []interface{}{"billing, eu"}, `"billing, eu"`, false},
+		{"escaped quote and backslash", []interface{}{`say "hi"`, `a\b`}, `"say \"hi\"", "a\\b"`, false},
+		{"integers", []interface{}{float64(42), float64(-7), 3}, "42, -7, 3", false},
+		{"decimal", []interface{}{1.5, 0.1234, -2.0004}, "1.5, 0.123, -2.0", false},
+		{"booleans", []interface{}{true, false}, "?1, ?0", false},
+		{"inner list", []interface{}{"a", []interface{}{"b", float64(1)}}, `"a", ("b" 1)`, false},
+		{"empty", []interface{}{}, "", false},
+		{"object", []interface{}{map[string]interface{}{"a": "b"}}, "", true},
+		{"null", []interface{}{nil}, "", true},
+		{"non-ASCII string", []interface{}{"café"}, "", true},
+		{"control character", []interface{}{"a\nb"}, "", true},
+		{"nested inner list", []interface{}{[]interface{}{[]interface{}{"a"}}}, "", true},
+		{"integer out of range", []interface{}{1e16}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatStructuredList(tt.items)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatStructuredList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("formatStructuredList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// errTemplateOutputTooLarge aborts a render that writes more than the
+// configured maximum header size.
+var errTemplateOutputTooLarge = errors.New("template output exceeds maximum size")
+
+// templateFuncs are the helper functions available to header templates.
+// Each takes the piped value last so it can be used in pipelines:
+//   {{.payload.roles | join ";"}}
+var templateFuncs = template.FuncMap{
+	"join":    templateJoin,
+	"lower":   templateLower,
+	"default": templateDefault,
+	"json":    templateJSON,
+	"b64":     templateBase64,
+}
+
+// outputFunc is appended to every printing action so that values print as
+// they would in a claim mapping: missing and null claims as empty strings
+// instead of text/template's "<no value>", arrays comma-joined and objects as
+// JSON instead of Go syntax.
+const outputFunc = "claimOutput"
+
+// compileTemplate parses a header template with the helper functions.
+//
+// Templates are executed against a map with the decoded JWT sections:
+//   {{.payload.sub}}                         payload claim
+//   {{.header.kid}}                          JWT header field
+//   {{.payload.tier | default "basic"}}      optional claim with fallback
+//   {{.payload.roles | join ","}}            array claim
+//
+// Missing and null claims print as empty strings, arrays as comma-joined
+// values and objects as JSON.
+//
+// Returns an error if the template does not parse.
+func compileTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("header").
+		Funcs(templateFuncs).
+		Funcs(template.FuncMap{outputFunc: templateOutput}).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			wrapOutput(t.Tree, t.Tree.Root)
+		}
+	}
+	return tmpl, nil
+}
+
+// wrapOutput appends outputFunc to the pipeline of every action under node
+// that prints its result. Actions declaring variables print nothing and are
+// left alone.
+func wrapOutput(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			wrapOutput(tree, child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) == 0 {
+			ident := parse.NewIdentifier(outputFunc).SetTree(tree).SetPos(n.Pos)
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{ident},
+			})
+		}
+	case *parse.IfNode:
+		wrapOutput(tree, n.List)
+		wrapOutput(tree, n.ElseList)
+	case *parse.RangeNode:
+		wrapOutput(tree, n.List)
+		wrapOutput(tree, n.ElseList)
+	case *parse.WithNode:
+		wrapOutput(tree, n.List)
+		wrapOutput(tree, n.ElseList)
+	}
+}
+
+// renderTemplate executes tmpl against the token's sections, aborting as soon as the output
+// exceeds maxSize bytes so large claims cannot force large allocations.
+// The result is still subject to SanitizeHeaderValue when injected.
+func renderTemplate(tmpl *template.Template, jwt *JWT, maxSize int) (string, error) {
+	data := map[string]interface{}{
+		"header":  jwt.Header,
+		"payload": jwt.Payload,
+	}
+
+	w := &limitedBuilder{max: maxSize}
+	if err := tmpl.Execute(w, data); err != nil {
+		if errors.Is(err, errTemplateOutputTooLarge) {
+			return "", errTemplateOutputTooLarge
+		}
+		return "", fmt.Errorf("template execution failed: %w", err)
+	}
+	return w.String(), nil
+}
+
+// limitedBuilder is an io.Writer collecting at most max bytes.
+type limitedBuilder struct {
+	buf strings.Builder
+	max int
+}
+
+// Write appends p, failing with errTemplateOutputTooLarge past max bytes.
+func (b *limitedBuilder) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.max {
+		return 0, errTemplateOutputTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the collected output.
+func (b *limitedBuilder) String() string {
+	return b.buf.String()
+}
+
+// templateString converts a template value with ConvertClaimToString,
+// treating unconvertible values as empty.
+func templateString(value interface{}) string {
+	s, err := ConvertClaimToString(value, "comma")
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+// templateOutput formats a printed value like an injected claim: nil (a
+// missing key or a null claim) becomes "", arrays are comma-joined and objects
+// become JSON. Values ConvertClaimToString does not support are returned
+// unchanged for text/template to print.
+func templateOutput(value interface{}) interface{} {
+	s, err := ConvertClaimToString(value, "comma")
+	if err != nil {
+		return value
+	}
+	return s
+}
+
+// templateJoin joins array elements with sep; scalars are returned as strings.
+func templateJoin(sep string, value interface{}) string {
+	arr, ok := value.([]interface{})
+	if !ok {
+		return templateString(value)
+	}
+	parts := make([]string, 0, len(arr))
+	for _, elem := range arr {
+		parts = append(parts, templateString(elem))
+	}
+	return strings.Join(parts, sep)
+}
+
+// templateLower lowercases the string form of value.
+func templateLower(value interface{}) string {
+	return strings.ToLower(templateString(value))
+}
+
+// templateDefault returns def when value is nil, an empty string or an empty array.
+func templateDefault(def, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return def
+		}
+	}
+	return value
+}
+
+// templateJSON marshals value as compact JSON.
+func templateJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// templateBase64 encodes the string form of value with standard base64.
+func templateBase64(value interface{}) string {
+	return base64.StdEncoding.EncodeToString([]byte(templateString(value)))
+}
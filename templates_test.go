@@ -0,0 +1,113 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCompileTemplate_Invalid verifies template parse errors are reported
+func TestCompileTemplate_Invalid(t *testing.T) {
+	tests := []string{
+		"{{.payload.sub",
+		"{{unknownFunc .payload.sub}}",
+		"{{if .payload.sub}}unterminated",
+	}
+
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			if _, err := compileTemplate(text); err == nil {
+				t.Error("compileTemplate() expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestRenderTemplate verifies section access and helper functions
+func TestRenderTemplate(t *testing.T) {
+	jwt := &JWT{
+		Header: map[string]interface{}{"alg": "RS256", "kid": "key-1"},
+		Payload: map[string]interface{}{
+			"sub":    "42",
+			"email":  "Jane@Example.COM",
+			"tenant": "acme",
+			"roles":  []interface{}{"admin", "user"},
+			"org":    map[string]interface{}{"id": "org-1"},
+			"empty":  "",
+			"null":   nil,
+			"quote":  "<no value>",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"composite", "tenant={{.payload.tenant}};user={{.payload.sub}};role={{index .payload.roles 0}}", "tenant=acme;user=42;role=admin"},
+		{"header section", "{{.header.kid}}", "key-1"},
+		{"nested claim", "{{.payload.org.id}}", "org-1"},
+		{"join", `{{.payload.roles | join ";"}}`, "admin;user"},
+		{"join scalar", `{{join "," .payload.sub}}`, "42"},
+		{"lower", "{{.payload.email | lower}}", "jane@example.com"},
+		{"default missing", `{{.payload.tier | default "basic"}}`, "basic"},
+		{"default missing nested", `{{.payload.missing.deeper | default "none"}}`, "none"},
+		{"default empty string", `{{.payload.empty | default "n/a"}}`, "n/a"},
+		{"default present", `{{.payload.tenant | default "basic"}}`, "acme"},
+		{"json array", "{{.payload.roles | json}}", `["admin","user"]`},
+		{"json object", "{{.payload.org | json}}", `{"id":"org-1"}`},
+		{"b64", "{{.payload.org | json | b64}}", "eyJpZCI6Im9yZy0xIn0="},
+		{"missing claim renders empty", "user={{.payload.missing}}", "user="},
+		{"null claim renders empty", "user={{.payload.null}}", "user="},
+		{"missing nested claim renders empty", "{{.payload.missing.deeper}}", ""},
+		{"missing claim in range", "{{range .payload.roles}}{{$.payload.missing}}{{.}};{{end}}", "admin;user;"},
+		{"missing claim in defined template", `{{define "t"}}[{{.payload.missing}}]{{end}}{{template "t" .}}`, "[]"},
+		{"variable declaration", "{{$t := .payload.tenant}}{{$t}}", "acme"},
+		{"bare array", "{{.payload.roles}}", "admin, user"},
+		{"bare object", "{{.payload.org}}", `{"id":"org-1"}`},
+		{"bare array element", "{{index .payload.roles 1}}", "user"},
+		{"function result", "{{len .payload.roles}}", "2"},
+		{"literal text kept", "{{.payload.quote}}", "<no value>"},
+		{"conditional", `{{if .payload.org}}org={{.payload.org.id}}{{end}}`, "org=org-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := compileTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("compileTemplate() error: %v", err)
+			}
+			got, err := renderTemplate(tmpl, jwt, 8192)
+			if err != nil {
+				t.Fatalf("renderTemplate() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRenderTemplate_OutputLimit verifies rendering stops once output exceeds the size limit
+func TestRenderTemplate_OutputLimit(t *testing.T) {
+	roles := make([]interface{}, 1000)
+	for i := range roles {
+		roles[i] = strings.Repeat("r", 100)
+	}
+	jwt := &JWT{Header: map[string]interface{}{}, Payload: map[string]interface{}{"roles": roles, "sub": "42"}}
+
+	tmpl, err := compileTemplate("{{range .payload.roles}}{{.}},{{end}}")
+	if err != nil {
+		t.Fatalf("compileTemplate() error: %v", err)
+	}
+	if _, err := renderTemplate(tmpl, jwt, 8192); err != errTemplateOutputTooLarge {
+		t.Errorf("renderTemplate() error = %v, want %v", err, errTemplateOutputTooLarge)
+	}
+
+	small, _ := compileTemplate("user={{.payload.sub}}")
+	if got, err := renderTemplate(small, jwt, 7); err != nil || got != "user=42" {
+		t.Errorf("renderTemplate() at exact limit = %q, %v; want %q, nil", got, err, "user=42")
+	}
+	if _, err := renderTemplate(small, jwt, 6); err != errTemplateOutputTooLarge {
+		t.Errorf("renderTemplate() over limit error = %v, want %v", err, errTemplateOutputTooLarge)
+	}
+}
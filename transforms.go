@@ -0,0 +1,175 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// transformTypes lists the supported transform types.
+var transformTypes = map[string]bool{
+	"lower":       true,
+	"stripPrefix": true,
+	"base64":      true,
+	"truncate":    true,
+	"regex":       true,
+	"lookup":      true,
+}
+
+// compiledTransform is a Transform with its regex precompiled and capture
+// group resolved. It is immutable after compilation and safe for concurrent use.
+type compiledTransform struct {
+	transform Transform
+	pattern   *regexp.Regexp
+	group     int
+}
+
+// compileTransform validates a Transform and prepares it for application.
+//
+// Returns an error if:
+//   - type is empty or unknown
+//   - "stripPrefix" or "regex" have no value, or "lookup" has no table
+//   - "truncate" has a length below 1
+//   - A regex does not compile or group exceeds its capture groups
+func compileTransform(t Transform) (*compiledTransform, error) {
+	if !transformTypes[t.Type] {
+		return nil, fmt.Errorf("invalid transform type '%s'", t.Type)
+	}
+
+	ct := &compiledTransform{transform: t}
+
+	switch t.Type {
+	case "stripPrefix":
+		if t.Value == "" {
+			return nil, fmt.Errorf("transform 'stripPrefix' requires a value")
+		}
+	case "truncate":
+		if t.Length < 1 {
+			return nil, fmt.Errorf("transform 'truncate' requires a length greater than 0")
+		}
+	case "regex":
+		if t.Value == "" {
+			return nil, fmt.Errorf("transform 'regex' requires a value")
+		}
+		re, err := regexp.Compile(t.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %w", t.Value, err)
+		}
+		if t.Group < 0 || t.Group > re.NumSubexp() {
+			return nil, fmt.Errorf("transform 'regex' group %d out of range, pattern has %d groups", t.Group, re.NumSubexp())
+		}
+		ct.pattern = re
+		ct.group = t.Group
+		if ct.group == 0 && re.NumSubexp() > 0 {
+			ct.group = 1
+		}
+	case "lookup":
+		if len(t.Table) == 0 {
+			return nil, fmt.Errorf("transform 'lookup' requires a table")
+		}
+	}
+
+	return ct, nil
+}
+
+// compileTransforms compiles an ordered transform list. Every invalid
+// transform is reported, prefixed with its index, in one joined error.
+func compileTransforms(transforms []Transform) ([]*compiledTransform, error) {
+	compiled := make([]*compiledTransform, 0, len(transforms))
+	var errs []error
+	for i, t := range transforms {
+		ct, err := compileTransform(t)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transform %d: %w", i, err))
+			continue
+		}
+		compiled = append(compiled, ct)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return compiled, nil
+}
+
+// apply runs the transform on a header value.
+//
+// Returns an error when the value cannot be transformed: the regex does not
+// match, or a lookup has no entry and no default.
+func (t *compiledTransform) apply(value string) (string, error) {
+	switch t.transform.Type {
+	case "lower":
+		return strings.ToLower(value), nil
+
+	case "stripPrefix":
+		return strings.TrimPrefix(value, t.transform.Value), nil
+
+	case "base64":
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+
+	case "truncate":
+		// Count runes so multi-byte characters are never split
+		if utf8.RuneCountInString(value) <= t.transform.Length {
+			return value, nil
+		}
+		runes := []rune(value)
+		return string(runes[:t.transform.Length]), nil
+
+	case "regex":
+		match := t.pattern.FindStringSubmatch(value)
+		if match == nil {
+			return "", fmt.Errorf("value does not match regex '%s'", t.transform.Value)
+		}
+		return match[t.group], nil
+
+	case "lookup":
+		if mapped, ok := t.transform.Table[value]; ok {
+			return mapped, nil
+		}
+		if t.transform.Default != "" {
+			return t.transform.Default, nil
+		}
+		return "", fmt.Errorf("no lookup entry for value")
+	}
+
+	return value, nil
+}
+
+// applyTransforms runs transforms in order, stopping at the first failure.
+//
+// Example:
+//   transforms, _ := compileTransforms([]Transform{
+//       {Type: "stripPrefix", Value: "auth0|"},
+//       {Type: "lower"},
+//   })
+//   value, _ := applyTransforms(transforms, "auth0|ABC123")
+//   // Returns: "abc123"
+func applyTransforms(transforms []*compiledTransform, value string) (string, error) {
+	for _, t := range transforms {
+		var err error
+		value, err = t.apply(value)
+		if err != nil {
+			return "", fmt.Errorf("transform '%s': %w", t.transform.Type, err)
+		}
+	}
+	return value, nil
+}
+
+// transformValues applies transforms to each value, as produced for
+// arrayFormat "multi". Any failing value fails the whole set.
+func transformValues(transforms []*compiledTransform, values []string) ([]string, error) {
+	if len(transforms) == 0 {
+		return values, nil
+	}
+	transformed := make([]string, 0, len(values))
+	for _, value := range values {
+		t, err := applyTransforms(transforms, value)
+		if err != nil {
+			return nil, err
+		}
+		transformed = append(transformed, t)
+	}
+	return transformed, nil
+}
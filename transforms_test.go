@@ -0,0 +1,106 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"testing"
+)
+
+// TestCompileTransform_Invalid verifies transform validation errors
+func TestCompileTransform_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+	}{
+		{"missing type", Transform{}},
+		{"unknown type", Transform{Type: "upperCamel"}},
+		{"stripPrefix without value", Transform{Type: "stripPrefix"}},
+		{"truncate without length", Transform{Type: "truncate"}},
+		{"truncate negative length", Transform{Type: "truncate", Length: -1}},
+		{"regex without value", Transform{Type: "regex"}},
+		{"invalid regex", Transform{Type: "regex", Value: "([a-z"}},
+		{"regex group out of range", Transform{Type: "regex", Value: "^(a)(b)$", Group: 3}},
+		{"regex negative group", Transform{Type: "regex", Value: "^(a)$", Group: -1}},
+		{"lookup without table", Transform{Type: "lookup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compileTransform(tt.transform); err == nil {
+				t.Error("compileTransform() expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestCompiledTransform_Apply verifies each transform type
+func TestCompiledTransform_Apply(t *testing.T) {
+	tiers := map[string]string{"gold": "premium", "silver": "standard"}
+
+	tests := []struct {
+		name      string
+		transform Transform
+		input     string
+		want      string
+		wantErr   bool
+	}{
+		{"lower", Transform{Type: "lower"}, "Test@Example.COM", "test@example.com", false},
+		{"stripPrefix present", Transform{Type: "stripPrefix", Value: "auth0|"}, "auth0|abc123", "abc123", false},
+		{"stripPrefix absent", Transform{Type: "stripPrefix", Value: "auth0|"}, "google|abc123", "google|abc123", false},
+		{"base64", Transform{Type: "base64"}, `{"tenant":"acme"}`, "eyJ0ZW5hbnQiOiJhY21lIn0=", false},
+		{"truncate longer", Transform{Type: "truncate", Length: 5}, "abcdefgh", "abcde", false},
+		{"truncate shorter", Transform{Type: "truncate", Length: 10}, "abc", "abc", false},
+		{"truncate multi-byte", Transform{Type: "truncate", Length: 2}, "日本語", "日本", false},
+		{"regex default group", Transform{Type: "regex", Value: `^[^@]+@(.+)$`}, "user@example.com", "example.com", false},
+		{"regex explicit group", Transform{Type: "regex", Value: `^([^@]+)@(.+)$`, Group: 2}, "user@example.com", "example.com", false},
+		{"regex whole match", Transform{Type: "regex", Value: `[0-9]+`}, "order-42-x", "42", false},
+		{"regex no match", Transform{Type: "regex", Value: `^[0-9]+$`}, "abc", "", true},
+		{"lookup hit", Transform{Type: "lookup", Table: tiers}, "gold", "premium", false},
+		{"lookup miss with default", Transform{Type: "lookup", Table: tiers, Default: "basic"}, "bronze", "basic", false},
+		{"lookup miss", Transform{Type: "lookup", Table: tiers}, "bronze", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, err := compileTransform(tt.transform)
+			if err != nil {
+				t.Fatalf("compileTransform() error: %v", err)
+			}
+			got, err := ct.apply(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyTransforms_Order verifies transforms run in configuration order
+func TestApplyTransforms_Order(t *testing.T) {
+	transforms, err := compileTransforms([]Transform{
+		{Type: "stripPrefix", Value: "auth0|"},
+		{Type: "lower"},
+		{Type: "truncate", Length: 6},
+	})
+	if err != nil {
+		t.Fatalf("compileTransforms() error: %v", err)
+	}
+
+	got, err := applyTransforms(transforms, "auth0|ABCDEF123")
+	if err != nil {
+		t.Fatalf("applyTransforms() error: %v", err)
+	}
+	if got != "abcdef" {
+		t.Errorf("applyTransforms() = %q, want %q", got, "abcdef")
+	}
+
+	// Truncating first keeps only "auth0|", which stripPrefix then removes
+	reversed, _ := compileTransforms([]Transform{
+		{Type: "truncate", Length: 6},
+		{Type: "stripPrefix", Value: "auth0|"},
+	})
+	got, _ = applyTransforms(reversed, "auth0|ABCDEF123")
+	if got != "" {
+		t.Errorf("applyTransforms() reversed = %q, want empty string", got)
+	}
+}
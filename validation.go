@@ -0,0 +1,187 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ClaimValidationError reports a registered claim that failed validation.
+// Claim holds the offending claim name so it can be logged and surfaced.
+type ClaimValidationError struct {
+	// Claim is the name of the claim that failed (e.g. "exp")
+	Claim string
+
+	// Reason is a human-readable description of the failure
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *ClaimValidationError) Error() string {
+	return fmt.Sprintf("JWT claim '%s' validation failed: %s", e.Claim, e.Reason)
+}
+
+// ValidateTimeClaims checks the registered time claims of a JWT payload
+// (RFC 7519 Section 4.1.4-4.1.6) against the given time.
+//
+// Rules:
+//   - exp: now must be before exp + clockSkew
+//   - nbf: now must not be before nbf - clockSkew
+//   - iat: must not be in the future beyond clockSkew
+//   - maxTokenAge (when > 0): iat is required and now must be before
+//     iat + maxTokenAge + clockSkew
+//
+// Absent exp, nbf and iat claims are accepted unless maxTokenAge requires iat.
+//
+// Example:
+//   err := ValidateTimeClaims(jwt.Payload, time.Now(), 30*time.Second, 0)
+//   // err is a *ClaimValidationError with Claim "exp" for an expired token
+//
+// Returns a *ClaimValidationError if any check fails or a time claim is not
+// a number.
+func ValidateTimeClaims(payload map[string]interface{}, now time.Time, clockSkew, maxTokenAge time.Duration) error {
+	exp, hasExp, err := numericDateClaim(payload, "exp")
+	if err != nil {
+		return err
+	}
+	if hasExp && !now.Before(exp.Add(clockSkew)) {
+		return &ClaimValidationError{Claim: "exp", Reason: fmt.Sprintf("token expired at %s", exp.UTC().Format(time.RFC3339))}
+	}
+
+	nbf, hasNbf, err := numericDateClaim(payload, "nbf")
+	if err != nil {
+		return err
+	}
+	if hasNbf && now.Before(nbf.Add(-clockSkew)) {
+		return &ClaimValidationError{Claim: "nbf", Reason: fmt.Sprintf("token not valid before %s", nbf.UTC().Format(time.RFC3339))}
+	}
+
+	iat, hasIat, err := numericDateClaim(payload, "iat")
+	if err != nil {
+		return err
+	}
+	if hasIat && now.Before(iat.Add(-clockSkew)) {
+		return &ClaimValidationError{Claim: "iat", Reason: fmt.Sprintf("token issued in the future at %s", iat.UTC().Format(time.RFC3339))}
+	}
+
+	if maxTokenAge > 0 {
+		if !hasIat {
+			return &ClaimValidationError{Claim: "iat", Reason: "claim is required when maxTokenAge is set"}
+		}
+		if !now.Before(iat.Add(maxTokenAge + clockSkew)) {
+			return &ClaimValidationError{Claim: "iat", Reason: fmt.Sprintf("token older than maximum age %s", maxTokenAge)}
+		}
+	}
+
+	return nil
+}
+
+// Bounds of an accepted NumericDate: 0001-01-01T00:00:00Z and
+// 9999-12-31T23:59:59Z. They lie well inside the int64 seconds range, so the
+// conversion and the clock skew arithmetic on the result cannot overflow.
+const (
+	minNumericDate = -62135596800
+	maxNumericDate = 253402300799
+)
+
+// numericDateClaim reads an RFC 7519 NumericDate (seconds since the epoch,
+// possibly fractional) from the payload. The boolean reports whether the
+// claim was present. Dates outside years 1-9999 are rejected.
+func numericDateClaim(payload map[string]interface{}, name string) (time.Time, bool, error) {
+	value, ok := payload[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	seconds, ok := claimNumber(value)
+	if !ok || math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, true, &ClaimValidationError{Claim: name, Reason: "not a numeric date"}
+	}
+
+	if seconds < minNumericDate || seconds > maxNumericDate {
+		return time.Time{}, true, &ClaimValidationError{Claim: name, Reason: "numeric date out of range"}
+	}
+
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)), true, nil
+}
+
+// ValidateIssuer checks the 'iss' claim against a list of accepted issuers.
+// Issuers are compared as exact, case-sensitive strings (RFC 7519 Section 4.1.1).
+// An empty allow-list accepts any issuer, including a missing claim.
+//
+// Example:
+//   err := ValidateIssuer(jwt.Payload, []string{"https://idp.example.com/"})
+//
+// Returns a *ClaimValidationError if 'iss' is missing, not a string, or not allowed.
+func ValidateIssuer(payload map[string]interface{}, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	value, ok := payload["iss"]
+	if !ok {
+		return &ClaimValidationError{Claim: "iss", Reason: "claim is missing"}
+	}
+	iss, ok := value.(string)
+	if !ok {
+		return &ClaimValidationError{Claim: "iss", Reason: "not a string"}
+	}
+
+	for _, a := range allowed {
+		if iss == a {
+			return nil
+		}
+	}
+
+	return &ClaimValidationError{Claim: "iss", Reason: fmt.Sprintf("issuer '%s' is not allowed", iss)}
+}
+
+// ValidateAudience checks the 'aud' claim against a list of accepted audiences.
+// Per RFC 7519 Section 4.1.3, 'aud' may be a single string or an array of
+// strings; the token is accepted when any of its audiences is allowed.
+// An empty allow-list accepts any audience, including a missing claim.
+//
+// Example:
+//   // {"aud": ["billing-api", "reports-api"]}
+//   err := ValidateAudience(jwt.Payload, []string{"reports-api"})
+//   // err == nil
+//
+// Returns a *ClaimValidationError if 'aud' is missing, malformed, or has no
+// allowed audience.
+func ValidateAudience(payload map[string]interface{}, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	value, ok := payload["aud"]
+	if !ok {
+		return &ClaimValidationError{Claim: "aud", Reason: "claim is missing"}
+	}
+
+	var audiences []string
+	switch v := value.(type) {
+	case string:
+		audiences = []string{v}
+	case []interface{}:
+		for _, elem := range v {
+			aud, ok := elem.(string)
+			if !ok {
+				return &ClaimValidationError{Claim: "aud", Reason: "array contains a non-string value"}
+			}
+			audiences = append(audiences, aud)
+		}
+	default:
+		return &ClaimValidationError{Claim: "aud", Reason: "not a string or array of strings"}
+	}
+
+	for _, aud := range audiences {
+		for _, a := range allowed {
+			if aud == a {
+				return nil
+			}
+		}
+	}
+
+	return &ClaimValidationError{Claim: "aud", Reason: "no allowed audience in token"}
+}
@@ -0,0 +1,126 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestValidateTimeClaims verifies exp, nbf, iat and maxTokenAge checks with clock skew
+func TestValidateTimeClaims(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	epoch := float64(now.Unix())
+
+	tests := []struct {
+		name        string
+		payload     map[string]interface{}
+		skew        time.Duration
+		maxAge      time.Duration
+		wantClaim   string
+		wantSuccess bool
+	}{
+		{"no time claims", map[string]interface{}{"sub": "1"}, 0, 0, "", true},
+		{"exp in future", map[string]interface{}{"exp": epoch + 60}, 0, 0, "", true},
+		{"exp in past", map[string]interface{}{"exp": epoch - 60}, 0, 0, "exp", false},
+		{"exp equal to now", map[string]interface{}{"exp": epoch}, 0, 0, "exp", false},
+		{"exp in past within skew", map[string]interface{}{"exp": epoch - 20}, 30 * time.Second, 0, "", true},
+		{"exp in past beyond skew", map[string]interface{}{"exp": epoch - 40}, 30 * time.Second, 0, "exp", false},
+		{"fractional exp", map[string]interface{}{"exp": epoch + 0.5}, 0, 0, "", true},
+		{"nbf in past", map[string]interface{}{"nbf": epoch - 60}, 0, 0, "", true},
+		{"nbf in future", map[string]interface{}{"nbf": epoch + 60}, 0, 0, "nbf", false},
+		{"nbf in future within skew", map[string]interface{}{"nbf": epoch + 20}, 30 * time.Second, 0, "", true},
+		{"iat in past", map[string]interface{}{"iat": epoch - 60}, 0, 0, "", true},
+		{"iat in future", map[string]interface{}{"iat": epoch + 60}, 0, 0, "iat", false},
+		{"maxTokenAge satisfied", map[string]interface{}{"iat": epoch - 60}, 0, time.Hour, "", true},
+		{"maxTokenAge exceeded", map[string]interface{}{"iat": epoch - 7200}, 0, time.Hour, "iat", false},
+		{"maxTokenAge without iat", map[string]interface{}{"exp": epoch + 60}, 0, time.Hour, "iat", false},
+		{"exp not a number", map[string]interface{}{"exp": "tomorrow"}, 0, 0, "exp", false},
+		{"nbf not a number", map[string]interface{}{"nbf": true}, 0, 0, "nbf", false},
+		{"nbf beyond int64 seconds", map[string]interface{}{"nbf": 1e19}, 0, 0, "nbf", false},
+		{"nbf near int64 max", map[string]interface{}{"nbf": 9.2e18}, 30 * time.Second, 0, "nbf", false},
+		{"exp below int64 seconds", map[string]interface{}{"exp": -1e19}, 0, 0, "exp", false},
+		{"exp after year 9999", map[string]interface{}{"exp": 253402300800.0}, 0, 0, "exp", false},
+		{"exp at year 9999", map[string]interface{}{"exp": 253402300799.0}, 0, 0, "", true},
+		{"iat far in past", map[string]interface{}{"iat": -1e300}, 0, 0, "iat", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTimeClaims(tt.payload, now, tt.skew, tt.maxAge)
+			if tt.wantSuccess {
+				if err != nil {
+					t.Errorf("ValidateTimeClaims() unexpected error: %v", err)
+				}
+				return
+			}
+
+			var claimErr *ClaimValidationError
+			if !errors.As(err, &claimErr) {
+				t.Fatalf("ValidateTimeClaims() error = %v, want *ClaimValidationError", err)
+			}
+			if claimErr.Claim != tt.wantClaim {
+				t.Errorf("ClaimValidationError.Claim = %q, want %q", claimErr.Claim, tt.wantClaim)
+			}
+		})
+	}
+}
+
+// TestValidateIssuer verifies exact-match issuer checks
+func TestValidateIssuer(t *testing.T) {
+	allowed := []string{"https://idp-a.example.com/", "https://idp-b.example.com/"}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		allowed []string
+		wantErr bool
+	}{
+		{"no allow-list", map[string]interface{}{}, nil, false},
+		{"allowed issuer", map[string]interface{}{"iss": "https://idp-b.example.com/"}, allowed, false},
+		{"unknown issuer", map[string]interface{}{"iss": "https://evil.example.com/"}, allowed, true},
+		{"trailing slash differs", map[string]interface{}{"iss": "https://idp-a.example.com"}, allowed, true},
+		{"case differs", map[string]interface{}{"iss": "HTTPS://IDP-A.EXAMPLE.COM/"}, allowed, true},
+		{"missing iss", map[string]interface{}{"sub": "1"}, allowed, true},
+		{"non-string iss", map[string]interface{}{"iss": float64(1)}, allowed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIssuer(tt.payload, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIssuer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestValidateAudience verifies string and array audience checks
+func TestValidateAudience(t *testing.T) {
+	allowed := []string{"reports-api"}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		allowed []string
+		wantErr bool
+	}{
+		{"no allow-list", map[string]interface{}{}, nil, false},
+		{"string audience allowed", map[string]interface{}{"aud": "reports-api"}, allowed, false},
+		{"string audience not allowed", map[string]interface{}{"aud": "billing-api"}, allowed, true},
+		{"array containing allowed", map[string]interface{}{"aud": []interface{}{"billing-api", "reports-api"}}, allowed, false},
+		{"array without allowed", map[string]interface{}{"aud": []interface{}{"billing-api"}}, allowed, true},
+		{"empty array", map[string]interface{}{"aud": []interface{}{}}, allowed, true},
+		{"array with non-string", map[string]interface{}{"aud": []interface{}{"reports-api", float64(1)}}, allowed, true},
+		{"object audience", map[string]interface{}{"aud": map[string]interface{}{"name": "reports-api"}}, allowed, true},
+		{"missing aud", map[string]interface{}{"sub": "1"}, allowed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAudience(tt.payload, tt.allowed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAudience() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
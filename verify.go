@@ -0,0 +1,454 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for verification keys.
+// Keys below 2048 bits are considered breakable (NIST SP 800-131A).
+const minRSAKeyBits = 2048
+
+// ErrInvalidSignature is returned when no configured key produces a signature
+// matching the one carried by the token.
+var ErrInvalidSignature = errors.New("invalid JWT signature")
+
+// ErrAlgorithmNotAllowed is returned when the token's 'alg' header is not in
+// the configured allow-list, or is the unsecured "none" algorithm.
+var ErrAlgorithmNotAllowed = errors.New("JWT algorithm not allowed")
+
+// ErrUnknownKeyID is returned when the token's 'kid' header does not match any
+// configured key and there are no keys without an identifier to fall back to.
+var ErrUnknownKeyID = errors.New("unknown JWT key ID")
+
+// algorithm describes how a JWS 'alg' value is verified.
+type algorithm struct {
+	// family is the key family the algorithm belongs to ("HMAC", "RSA", "RSA-PSS", "ECDSA", "EdDSA")
+	family string
+
+	// hash is the digest used for the signing input (unused for EdDSA)
+	hash crypto.Hash
+
+	// curve is the elliptic curve an ECDSA key must use for this algorithm
+	curve string
+}
+
+// algorithms maps each supported JWS algorithm name to its verification parameters.
+var algorithms = map[string]algorithm{
+	"HS256": {family: "HMAC", hash: crypto.SHA256},
+	"HS384": {family: "HMAC", hash: crypto.SHA384},
+	"HS512": {family: "HMAC", hash: crypto.SHA512},
+	"RS256": {family: "RSA", hash: crypto.SHA256},
+	"RS384": {family: "RSA", hash: crypto.SHA384},
+	"RS512": {family: "RSA", hash: crypto.SHA512},
+	"PS256": {family: "RSA-PSS", hash: crypto.SHA256},
+	"PS384": {family: "RSA-PSS", hash: crypto.SHA384},
+	"PS512": {family: "RSA-PSS", hash: crypto.SHA512},
+	"ES256": {family: "ECDSA", hash: crypto.SHA256, curve: "P-256"},
+	"ES384": {family: "ECDSA", hash: crypto.SHA384, curve: "P-384"},
+	"ES512": {family: "ECDSA", hash: crypto.SHA512, curve: "P-521"},
+	"EdDSA": {family: "EdDSA"},
+}
+
+// Key is a single verification key loaded from the plugin configuration.
+// The material field holds the algorithm-specific key:
+//   - []byte for HMAC shared secrets
+//   - *rsa.PublicKey for RSA and RSA-PSS public keys
+//   - *ecdsa.PublicKey for ECDSA public keys on P-256, P-384 or P-521
+//   - ed25519.PublicKey for EdDSA public keys
+type Key struct {
+	// ID is the optional key identifier matched against the JWT 'kid' header
+	ID string
+
+	// Algorithm optionally restricts the key to a single JWS algorithm (JWK 'alg')
+	Algorithm string
+
+	// material is the parsed key used to verify signatures
+	material interface{}
+}
+
+// KeySet is the immutable collection of keys used to verify token signatures.
+// It is built once during plugin initialization and shared across requests.
+type KeySet struct {
+	keys []*Key
+}
+
+// LoadKeySet builds a KeySet from the verification configuration.
+// Secrets, PEM keys and the JWKS document configured by file path are read
+// from disk at load time so that request processing never touches the filesystem.
+//
+// Returns an error if:
+//   - The jwksFile cannot be read or contains no usable keys
+//   - A key does not have exactly one of secret, secretFile, publicKey or publicKeyFile
+//   - A key file cannot be read or is empty
+//   - A public key is not valid PEM or is not a supported key type
+//   - An RSA key is smaller than 2048 bits
+//   - A key's algorithm is unknown or does not match the key type
+func LoadKeySet(cfg VerificationConfig) (*KeySet, error) {
+	ks := &KeySet{}
+
+	for i, kc := range cfg.Keys {
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("verification key %d: %w", i, err)
+		}
+		ks.keys = append(ks.keys, key)
+	}
+
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwksFile: %w", err)
+		}
+		keys, err := ParseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwksFile: %w", err)
+		}
+		ks.keys = append(ks.keys, keys...)
+	}
+
+	return ks, nil
+}
+
+// withKeys returns a new KeySet holding the keys of ks followed by extra.
+func (ks *KeySet) withKeys(extra []*Key) *KeySet {
+	keys := make([]*Key, 0, len(ks.keys)+len(extra))
+	keys = append(keys, ks.keys...)
+	keys = append(keys, extra...)
+	return &KeySet{keys: keys}
+}
+
+// hasKeyID reports whether any key in the set has the given ID.
+func (ks *KeySet) hasKeyID(kid string) bool {
+	for _, key := range ks.keys {
+		if key.ID == kid {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns the keys that may have signed a token with the given
+// 'kid' header value (empty when absent).
+//
+// Selection rules:
+//   - No kid: every key is a candidate
+//   - kid matches one or more keys: only those keys are candidates
+//   - kid matches nothing: keys without an ID are candidates, and if there
+//     are none the kid is reported as unknown (ErrUnknownKeyID)
+func (ks *KeySet) candidates(kid string) ([]*Key, error) {
+	if kid == "" {
+		return ks.keys, nil
+	}
+
+	var matched, anonymous []*Key
+	for _, key := range ks.keys {
+		switch key.ID {
+		case kid:
+			matched = append(matched, key)
+		case "":
+			anonymous = append(anonymous, key)
+		}
+	}
+
+	if len(matched) > 0 {
+		return matched, nil
+	}
+	if len(anonymous) > 0 {
+		return anonymous, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, kid)
+}
+
+// loadKey resolves a single KeyConfig into a Key.
+func loadKey(kc KeyConfig) (*Key, error) {
+	sources := 0
+	for _, v := range []string{kc.Secret, kc.SecretFile, kc.PublicKey, kc.PublicKeyFile} {
+		if v != "" {
+			sources++
+		}
+	}
+	if sources == 0 {
+		return nil, fmt.Errorf("one of secret, secretFile, publicKey or publicKeyFile is required")
+	}
+	if sources > 1 {
+		return nil, fmt.Errorf("secret, secretFile, publicKey and publicKeyFile are mutually exclusive")
+	}
+
+	material, err := loadKeyMaterial(kc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binding a key to an algorithm prevents e.g. an RSA public key from
+	// being accepted as an HMAC secret (algorithm confusion)
+	if kc.Algorithm != "" {
+		alg, ok := algorithms[kc.Algorithm]
+		if !ok {
+			return nil, fmt.Errorf("unsupported algorithm '%s'", kc.Algorithm)
+		}
+		if !keyMatchesAlgorithm(alg, material) {
+			return nil, fmt.Errorf("algorithm '%s' cannot be used with %s", kc.Algorithm, keyTypeName(material))
+		}
+	}
+
+	return &Key{ID: kc.KeyID, Algorithm: kc.Algorithm, material: material}, nil
+}
+
+// loadKeyMaterial reads the secret or public key configured in kc.
+func loadKeyMaterial(kc KeyConfig) (interface{}, error) {
+	switch {
+	case kc.Secret != "":
+		return []byte(kc.Secret), nil
+
+	case kc.SecretFile != "":
+		data, err := os.ReadFile(kc.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secretFile: %w", err)
+		}
+		// Trailing newlines are almost always an editor artifact, not key material
+		secret := strings.TrimRight(string(data), "\r\n")
+		if secret == "" {
+			return nil, fmt.Errorf("secretFile is empty")
+		}
+		return []byte(secret), nil
+
+	case kc.PublicKey != "":
+		return parsePublicKeyPEM([]byte(kc.PublicKey))
+
+	default:
+		data, err := os.ReadFile(kc.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read publicKeyFile: %w", err)
+		}
+		return parsePublicKeyPEM(data)
+	}
+}
+
+// keyMatchesAlgorithm reports whether key material belongs to the algorithm's
+// family (and, for ECDSA, uses the curve the algorithm requires).
+func keyMatchesAlgorithm(alg algorithm, material interface{}) bool {
+	switch k := material.(type) {
+	case []byte:
+		return alg.family == "HMAC"
+	case *rsa.PublicKey:
+		return alg.family == "RSA" || alg.family == "RSA-PSS"
+	case *ecdsa.PublicKey:
+		return alg.family == "ECDSA" && k.Curve.Params().Name == alg.curve
+	case ed25519.PublicKey:
+		return alg.family == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// keyTypeName describes key material for error messages.
+func keyTypeName(material interface{}) string {
+	switch material.(type) {
+	case []byte:
+		return "an HMAC secret"
+	case *rsa.PublicKey:
+		return "an RSA key"
+	case *ecdsa.PublicKey:
+		return "an ECDSA key on this curve"
+	case ed25519.PublicKey:
+		return "an Ed25519 key"
+	default:
+		return "an unknown key type"
+	}
+}
+
+// CheckAlgorithm verifies the token's 'alg' header against an allow-list.
+// An empty allow-list permits any algorithm.
+//
+// Example:
+//   err := CheckAlgorithm(jwt, []string{"RS256", "ES256"})
+//   // err wraps ErrAlgorithmNotAllowed for {"alg":"HS256"}
+func CheckAlgorithm(jwt *JWT, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	name, _ := jwt.Header["alg"].(string)
+	for _, a := range allowed {
+		if name == a {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: '%s'", ErrAlgorithmNotAllowed, name)
+}
+
+// parsePublicKeyPEM decodes a PEM block holding a public key.
+// Accepted block types are "PUBLIC KEY" (PKIX), "RSA PUBLIC KEY" (PKCS#1)
+// and "CERTIFICATE" (the certificate's subject public key is used).
+func parsePublicKeyPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key: no PEM block found")
+	}
+
+	var pub interface{}
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			pub = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("invalid public key: unsupported PEM block type '%s'", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %v", err)
+	}
+
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key size %d bits is below minimum of %d bits", k.N.BitLen(), minRSAKeyBits)
+		}
+		return k, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return k, nil
+		}
+		return nil, fmt.Errorf("invalid public key: unsupported ECDSA curve %s", k.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("invalid public key: unsupported key type %T", pub)
+	}
+}
+
+// VerifySignature checks the JWT signature against the keys in the set.
+// The algorithm is taken from the token's 'alg' header. Keys are selected by
+// the 'kid' header when present, and each selected key whose type (and JWK
+// 'alg', if set) is compatible with the algorithm is tried in turn.
+//
+// Supported algorithms:
+//   - HS256, HS384, HS512 (HMAC with SHA-2)
+//   - RS256, RS384, RS512 (RSASSA-PKCS1-v1_5 with SHA-2)
+//   - PS256, PS384, PS512 (RSASSA-PSS with SHA-2)
+//   - ES256, ES384, ES512 (ECDSA on P-256, P-384, P-521 with JOSE r||s signatures)
+//   - EdDSA (Ed25519)
+//
+// Example:
+//   jwt, _ := ParseJWT(token, false)
+//   if err := VerifySignature(jwt, keySet); err != nil {
+//       // reject the request
+//   }
+//
+// Returns an error if:
+//   - The 'alg' header is missing or not a string
+//   - The algorithm is "none" (ErrAlgorithmNotAllowed)
+//   - The algorithm is not supported
+//   - The signature segment is not valid base64url
+//   - The 'kid' header matches no key (ErrUnknownKeyID)
+//   - No key verifies the signature (ErrInvalidSignature)
+func VerifySignature(jwt *JWT, ks *KeySet) error {
+	name, ok := jwt.Header["alg"].(string)
+	if !ok || name == "" {
+		return fmt.Errorf("invalid JWT header: missing or non-string 'alg' field")
+	}
+
+	// Unsecured tokens carry no signature to verify, in any spelling
+	if strings.EqualFold(name, "none") {
+		return fmt.Errorf("%w: unsecured 'none' tokens are rejected", ErrAlgorithmNotAllowed)
+	}
+
+	alg, ok := algorithms[name]
+	if !ok {
+		return fmt.Errorf("unsupported JWT algorithm: %s", name)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(jwt.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid JWT signature encoding: %v", err)
+	}
+
+	kid, _ := jwt.Header["kid"].(string)
+	keys, err := ks.candidates(kid)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if key.Algorithm != "" && key.Algorithm != name {
+			continue
+		}
+		if verifyWithKey(alg, key, []byte(jwt.SigningInput), signature) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// verifyWithKey reports whether signature is valid for input under key.
+// Keys whose type does not match the algorithm family never verify.
+func verifyWithKey(alg algorithm, key *Key, input, signature []byte) bool {
+	if !keyMatchesAlgorithm(alg, key.material) {
+		return false
+	}
+
+	switch k := key.material.(type) {
+	case []byte:
+		mac := hmac.New(alg.hash.New, k)
+		mac.Write(input)
+		return hmac.Equal(mac.Sum(nil), signature)
+
+	case *rsa.PublicKey:
+		if alg.family == "RSA-PSS" {
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: alg.hash}
+			return rsa.VerifyPSS(k, alg.hash, digest(alg.hash, input), signature, opts) == nil
+		}
+		return rsa.VerifyPKCS1v15(k, alg.hash, digest(alg.hash, input), signature) == nil
+
+	case *ecdsa.PublicKey:
+		// JOSE encodes ECDSA signatures as fixed-width big-endian r||s, not ASN.1
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest(alg.hash, input), r, s)
+
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return false
+		}
+		return ed25519.Verify(k, input, signature)
+	}
+
+	return false
+}
+
+// digest hashes input with the given hash function.
+func digest(h crypto.Hash, input []byte) []byte {
+	hasher := h.New()
+	hasher.Write(input)
+	return hasher.Sum(nil)
+}
+
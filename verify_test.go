@@ -0,0 +1,558 @@
+package traefik_jwt_decoder_plugin
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testHMACSecret is the shared secret used to sign HMAC test tokens
+const testHMACSecret = "a-string-secret-at-least-256-bits-long"
+
+// testPayload is the payload used by signed test tokens
+const testPayload = `{"sub":"1234567890","email":"test@example.com","roles":["admin","user"]}`
+
+// signHMAC builds a compact JWT signed with the given HMAC algorithm and secret
+func signHMAC(t *testing.T, alg, secret, header, payload string) string {
+	t.Helper()
+
+	var h func() hash.Hash
+	switch alg {
+	case "HS256":
+		h = sha256.New
+	case "HS384":
+		h = sha512.New384
+	case "HS512":
+		h = sha512.New
+	default:
+		t.Fatalf("signHMAC: unsupported alg %s", alg)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// mustKeySet loads a KeySet from config or fails the test
+func mustKeySet(t *testing.T, cfg VerificationConfig) *KeySet {
+	t.Helper()
+	ks, err := LoadKeySet(cfg)
+	if err != nil {
+		t.Fatalf("LoadKeySet() failed: %v", err)
+	}
+	return ks
+}
+
+// TestVerifySignature_HMAC verifies HS256/HS384/HS512 signature checks
+func TestVerifySignature_HMAC(t *testing.T) {
+	ks := mustKeySet(t, VerificationConfig{
+		Enabled: true,
+		Keys:    []KeyConfig{{Secret: testHMACSecret}},
+	})
+
+	tests := []struct {
+		name    string
+		alg     string
+		secret  string
+		wantErr bool
+	}{
+		{"HS256 valid", "HS256", testHMACSecret, false},
+		{"HS384 valid", "HS384", testHMACSecret, false},
+		{"HS512 valid", "HS512", testHMACSecret, false},
+		{"HS256 wrong secret", "HS256", "wrong-secret", true},
+		{"HS512 wrong secret", "HS512", "wrong-secret", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signHMAC(t, tt.alg, tt.secret, `{"alg":"`+tt.alg+`","typ":"JWT"}`, testPayload)
+			jwt, err := ParseJWT(token, false)
+			if err != nil {
+				t.Fatalf("ParseJWT() failed: %v", err)
+			}
+
+			err = VerifySignature(jwt, ks)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+// TestVerifySignature_TamperedPayload verifies modified claims are rejected
+func TestVerifySignature_TamperedPayload(t *testing.T) {
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{Secret: testHMACSecret}}})
+
+	token := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, testPayload)
+	forged := signHMAC(t, "HS256", "attacker", `{"alg":"HS256"}`, `{"sub":"admin"}`)
+
+	// Splice the forged payload onto the legitimate signature
+	segs := strings.Split(token, ".")
+	forgedSegs := strings.Split(forged, ".")
+	tampered := segs[0] + "." + forgedSegs[1] + "." + segs[2]
+
+	jwt, err := ParseJWT(tampered, false)
+	if err != nil {
+		t.Fatalf("ParseJWT() failed: %v", err)
+	}
+	if err := VerifySignature(jwt, ks); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// TestVerifySignature_MultipleSecrets verifies any configured secret may match
+func TestVerifySignature_MultipleSecrets(t *testing.T) {
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{
+		{Secret: "old-secret"},
+		{Secret: testHMACSecret},
+	}})
+
+	token := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, testPayload)
+	jwt, _ := ParseJWT(token, false)
+	if err := VerifySignature(jwt, ks); err != nil {
+		t.Errorf("VerifySignature() unexpected error: %v", err)
+	}
+}
+
+// TestVerifySignature_InvalidHeader verifies errors for unusable 'alg' values and signatures
+func TestVerifySignature_InvalidHeader(t *testing.T) {
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{Secret: testHMACSecret}}})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing alg", signHMAC(t, "HS256", testHMACSecret, `{"typ":"JWT"}`, testPayload)},
+		{"non-string alg", signHMAC(t, "HS256", testHMACSecret, `{"alg":256}`, testPayload)},
+		{"unsupported alg", signHMAC(t, "HS256", testHMACSecret, `{"alg":"XX999"}`, testPayload)},
+		{"invalid signature encoding", "eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxMjMifQ.!!!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwt, err := ParseJWT(tt.token, false)
+			if err != nil {
+				t.Fatalf("ParseJWT() failed: %v", err)
+			}
+			if err := VerifySignature(jwt, ks); err == nil {
+				t.Error("VerifySignature() expected error, got nil")
+			}
+		})
+	}
+}
+
+// TestLoadKeySet_SecretFile verifies secrets can be read from disk
+func TestLoadKeySet_SecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(testHMACSecret+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{SecretFile: path}}})
+
+	token := signHMAC(t, "HS256", testHMACSecret, `{"alg":"HS256"}`, testPayload)
+	jwt, _ := ParseJWT(token, false)
+	if err := VerifySignature(jwt, ks); err != nil {
+		t.Errorf("VerifySignature() with secretFile unexpected error: %v", err)
+	}
+}
+
+// TestLoadKeySet_Invalid verifies key configuration errors
+func TestLoadKeySet_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		key  KeyConfig
+	}{
+		{"no key material", KeyConfig{KeyID: "k1"}},
+		{"secret and secretFile", KeyConfig{Secret: "s", SecretFile: "/tmp/s"}},
+		{"missing secretFile", KeyConfig{SecretFile: "/nonexistent/secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeySet(VerificationConfig{Keys: []KeyConfig{tt.key}})
+			if err == nil {
+				t.Error("LoadKeySet() expected error, got nil")
+			}
+		})
+	}
+}
+
+// rsaTestKeys caches generated RSA keys by size to keep the suite fast
+var (
+	rsaTestKeysMu sync.Mutex
+	rsaTestKeys   = map[int]*rsa.PrivateKey{}
+)
+
+// testRSAKey returns a cached RSA private key of the given size
+func testRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	rsaTestKeysMu.Lock()
+	defer rsaTestKeysMu.Unlock()
+
+	if key, ok := rsaTestKeys[bits]; ok {
+		return key
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	rsaTestKeys[bits] = key
+	return key
+}
+
+// publicKeyPEM encodes a public key as a PKIX "PUBLIC KEY" PEM block
+func publicKeyPEM(t *testing.T, pub interface{}) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// signRSA builds a compact JWT signed with an RS* or PS* algorithm
+func signRSA(t *testing.T, alg string, key *rsa.PrivateKey, header, payload string) string {
+	t.Helper()
+
+	hashes := map[string]crypto.Hash{
+		"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+		"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	}
+	h, ok := hashes[alg]
+	if !ok {
+		t.Fatalf("signRSA: unsupported alg %s", alg)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	hasher := h.New()
+	hasher.Write([]byte(input))
+
+	var sig []byte
+	var err error
+	if strings.HasPrefix(alg, "PS") {
+		sig, err = rsa.SignPSS(rand.Reader, key, h, hasher.Sum(nil), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	} else {
+		sig, err = rsa.SignPKCS1v15(rand.Reader, key, h, hasher.Sum(nil))
+	}
+	if err != nil {
+		t.Fatalf("signRSA: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// TestVerifySignature_RSA verifies RS* and PS* signature checks
+func TestVerifySignature_RSA(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	otherKey := testRSAKey(t, 3072)
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, &key.PublicKey)}}})
+
+	algs := []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	for _, alg := range algs {
+		t.Run(alg+" valid", func(t *testing.T) {
+			token := signRSA(t, alg, key, `{"alg":"`+alg+`"}`, testPayload)
+			jwt, _ := ParseJWT(token, false)
+			if err := VerifySignature(jwt, ks); err != nil {
+				t.Errorf("VerifySignature() unexpected error: %v", err)
+			}
+		})
+
+		t.Run(alg+" wrong key", func(t *testing.T) {
+			token := signRSA(t, alg, otherKey, `{"alg":"`+alg+`"}`, testPayload)
+			jwt, _ := ParseJWT(token, false)
+			if err := VerifySignature(jwt, ks); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+// TestVerifySignature_RSAPaddingMismatch verifies PKCS#1 v1.5 and PSS signatures are not interchangeable
+func TestVerifySignature_RSAPaddingMismatch(t *testing.T) {
+	key := testRSAKey(t, 2048)
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, &key.PublicKey)}}})
+
+	// Sign with PS256 but claim RS256 in the header
+	token := signRSA(t, "PS256", key, `{"alg":"RS256"}`, testPayload)
+	jwt, _ := ParseJWT(token, false)
+	if err := VerifySignature(jwt, ks); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// TestLoadKeySet_PEMFormats verifies accepted PEM encodings for RSA keys
+func TestLoadKeySet_PEMFormats(t *testing.T) {
+	key := testRSAKey(t, 2048)
+
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}))
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, []byte(publicKeyPEM(t, &key.PublicKey)), 0o600); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  KeyConfig
+	}{
+		{"PKIX inline", KeyConfig{PublicKey: publicKeyPEM(t, &key.PublicKey)}},
+		{"PKCS1 inline", KeyConfig{PublicKey: pkcs1}},
+		{"PKIX file", KeyConfig{PublicKeyFile: path}},
+	}
+
+	token := signRSA(t, "RS256", key, `{"alg":"RS256"}`, testPayload)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{tt.key}})
+			jwt, _ := ParseJWT(token, false)
+			if err := VerifySignature(jwt, ks); err != nil {
+				t.Errorf("VerifySignature() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestLoadKeySet_InvalidPublicKey verifies malformed and weak public keys are rejected
+func TestLoadKeySet_InvalidPublicKey(t *testing.T) {
+	weak := testRSAKey(t, 1024)
+	p224, _ := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+
+	tests := []struct {
+		name string
+		key  KeyConfig
+	}{
+		{"not PEM", KeyConfig{PublicKey: "not a pem block"}},
+		{"unsupported block type", KeyConfig{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1}}))}},
+		{"garbage DER", KeyConfig{PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte{1, 2, 3}}))}},
+		{"1024-bit RSA key", KeyConfig{PublicKey: publicKeyPEM(t, &weak.PublicKey)}},
+		{"P-224 ECDSA key", KeyConfig{PublicKey: publicKeyPEM(t, &p224.PublicKey)}},
+		{"secret and publicKey", KeyConfig{Secret: "s", PublicKey: publicKeyPEM(t, &weak.PublicKey)}},
+		{"missing publicKeyFile", KeyConfig{PublicKeyFile: "/nonexistent/key.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeySet(VerificationConfig{Keys: []KeyConfig{tt.key}}); err == nil {
+				t.Error("LoadKeySet() expected error, got nil")
+			}
+		})
+	}
+}
+
+// b64url decodes an unpadded base64url string or fails the test
+func b64url(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid base64url %q: %v", s, err)
+	}
+	return data
+}
+
+// signECDSA builds a compact JWT signed with ES256/ES384/ES512 using JOSE r||s encoding
+func signECDSA(t *testing.T, alg string, key *ecdsa.PrivateKey, header, payload string) string {
+	t.Helper()
+
+	hashes := map[string]crypto.Hash{"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512}
+	h, ok := hashes[alg]
+	if !ok {
+		t.Fatalf("signECDSA: unsupported alg %s", alg)
+	}
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+	hasher := h.New()
+	hasher.Write([]byte(input))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key, hasher.Sum(nil))
+	if err != nil {
+		t.Fatalf("signECDSA: %v", err)
+	}
+
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// TestVerifySignature_RFCVectors verifies the ES256 (RFC 7515 A.3) and Ed25519 (RFC 8037 A.4) examples
+func TestVerifySignature_RFCVectors(t *testing.T) {
+	// RFC 7515 Appendix A.3 P-256 public key
+	es256Key := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(b64url(t, "f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU")),
+		Y:     new(big.Int).SetBytes(b64url(t, "x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0")),
+	}
+	// RFC 8037 Appendix A.2 Ed25519 public key
+	edKey := ed25519.PublicKey(b64url(t, "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"))
+
+	tests := []struct {
+		name      string
+		key       interface{}
+		alg       string
+		input     string
+		signature string
+		wantErr   bool
+	}{
+		{
+			name:      "RFC 7515 A.3 ES256",
+			key:       es256Key,
+			alg:       "ES256",
+			input:     "eyJhbGciOiJFUzI1NiJ9.eyJpc3MiOiJqb2UiLA0KICJleHAiOjEzMDA4MTkzODAsDQogImh0dHA6Ly9leGFtcGxlLmNvbS9pc19yb290Ijp0cnVlfQ",
+			signature: "DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q",
+			wantErr:   false,
+		},
+		{
+			name:      "RFC 7515 A.3 ES256 with tampered input",
+			key:       es256Key,
+			alg:       "ES256",
+			input:     "eyJhbGciOiJFUzI1NiJ9.eyJpc3MiOiJtYWxsb3J5In0",
+			signature: "DtEhU3ljbEg8L38VWAfUAqOyKAM6-Xx-F4GawxaepmXFCgfTjDxw5djxLa8ISlSApmWQxfKTUJqPP3-Kg6NU1Q",
+			wantErr:   true,
+		},
+		{
+			name:      "RFC 8037 A.4 Ed25519",
+			key:       edKey,
+			alg:       "EdDSA",
+			input:     "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc",
+			signature: "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg",
+			wantErr:   false,
+		},
+		{
+			name:      "RFC 8037 A.4 Ed25519 with tampered input",
+			key:       edKey,
+			alg:       "EdDSA",
+			input:     "eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDQ0OCBzaWduaW5n",
+			signature: "hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, tt.key)}}})
+
+			// The RFC 8037 payload is not JSON, so build the JWT directly
+			jwt := &JWT{
+				Header:       map[string]interface{}{"alg": tt.alg},
+				Signature:    tt.signature,
+				SigningInput: tt.input,
+			}
+
+			err := VerifySignature(jwt, ks)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifySignature_ECDSA verifies ES* signatures and curve binding
+func TestVerifySignature_ECDSA(t *testing.T) {
+	keys := map[string]*ecdsa.PrivateKey{}
+	for alg, curve := range map[string]elliptic.Curve{"ES256": elliptic.P256(), "ES384": elliptic.P384(), "ES512": elliptic.P521()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate %s key: %v", alg, err)
+		}
+		keys[alg] = key
+	}
+
+	tests := []struct {
+		name     string
+		alg      string
+		signWith string
+		verifyAs string
+		wantErr  bool
+	}{
+		{"ES256 valid", "ES256", "ES256", "ES256", false},
+		{"ES384 valid", "ES384", "ES384", "ES384", false},
+		{"ES512 valid", "ES512", "ES512", "ES512", false},
+		{"ES256 wrong key", "ES256", "ES256", "ES384", true},
+		{"ES384 header with P-256 key", "ES384", "ES256", "ES256", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := keys[tt.signWith]
+			token := signECDSA(t, tt.signWith, signer, `{"alg":"`+tt.alg+`"}`, testPayload)
+			ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, &keys[tt.verifyAs].PublicKey)}}})
+
+			jwt, err := ParseJWT(token, false)
+			if err != nil {
+				t.Fatalf("ParseJWT() failed: %v", err)
+			}
+			err = VerifySignature(jwt, ks)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestVerifySignature_ECDSARejectsASN1 verifies DER-encoded ECDSA signatures are not accepted
+func TestVerifySignature_ECDSARejectsASN1(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, &key.PublicKey)}}})
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(testPayload))
+	sum := sha256.Sum256([]byte(input))
+	der, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatalf("SignASN1() failed: %v", err)
+	}
+
+	jwt, _ := ParseJWT(input+"."+base64.RawURLEncoding.EncodeToString(der), false)
+	if err := VerifySignature(jwt, ks); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// TestVerifySignature_EdDSA verifies Ed25519 signatures from generated keys
+func TestVerifySignature_EdDSA(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate Ed25519 key: %v", err)
+	}
+	ks := mustKeySet(t, VerificationConfig{Keys: []KeyConfig{{PublicKey: publicKeyPEM(t, pub)}}})
+
+	input := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(testPayload))
+	token := input + "." + base64.RawURLEncoding.EncodeToString(ed25519.Sign(priv, []byte(input)))
+
+	jwt, _ := ParseJWT(token, false)
+	if err := VerifySignature(jwt, ks); err != nil {
+		t.Errorf("VerifySignature() unexpected error: %v", err)
+	}
+
+	// An Ed25519 key must not verify an ES256 claim over the same bytes
+	jwt.Header["alg"] = "ES256"
+	if err := VerifySignature(jwt, ks); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifySignature() error = %v, want ErrInvalidSignature", err)
+	}
+}